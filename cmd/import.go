@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/wolf1996/HandWitch/pkg/core"
+)
+
+// openInput открываем входной файл, "-" означает stdin
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(path)
+}
+
+// writeImported пишем описания ручек в файл или в stdout, если путь не задан
+func writeImported(path string, container core.URLContrainer) error {
+	if path == "" {
+		return core.WriteDescriptionsYAML(os.Stdout, container)
+	}
+	output, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create output file %w", err)
+	}
+	defer output.Close()
+	return core.WriteDescriptionsYAML(output, container)
+}
+
+// importer функция построения описаний ручек из документа другого формата
+type importer func(reader io.Reader, options core.ImportOptions, logger *log.Entry) (core.URLContrainer, error)
+
+// runImport строим описания ручек из входного файла форматом format
+func runImport(format string, importHands importer) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		input, err := openInput(cmd.Flag("input").Value.String())
+		if err != nil {
+			return fmt.Errorf("Failed to open %s %w", format, err)
+		}
+		defer input.Close()
+		options := core.ImportOptions{
+			BaseURL: cmd.Flag("base-url").Value.String(),
+			Body:    cmd.Flag("body").Value.String(),
+		}
+		container, err := importHands(input, options, log.NewEntry(log.StandardLogger()))
+		if err != nil {
+			return fmt.Errorf("Failed to import %s %w", format, err)
+		}
+		log.Infof("Imported %d hands", len(container))
+		return writeImported(cmd.Flag("output").Value.String(), container)
+	}
+}
+
+// newImportCommand команда импорта из формата с общими флагами
+func newImportCommand(use string, short string, input string, format string, importHands importer) (*cobra.Command, error) {
+	comand := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE:  runImport(format, importHands),
+	}
+	comand.Flags().String("input", "", input+" path, - for stdin")
+	comand.Flags().String("output", "", "generated descriptions file path, stdout if empty")
+	comand.Flags().String("base-url", "", "url used instead of document servers or scheme and host of requests")
+	comand.Flags().String("body", core.DefaultImportedBody, "responce template of generated hands")
+	err := comand.MarkFlagRequired("input")
+	return comand, err
+}
+
+func registerImport(parentCmd *cobra.Command) (*cobra.Command, error) {
+	comand := cobra.Command{
+		Use:   "import",
+		Short: "Generates hands descriptions from other formats",
+		// импорту конфиг бота не нужен
+		PersistentPreRunE: prerunLog,
+	}
+	openAPI, err := newImportCommand("openapi", "Generates hands from OpenAPI 3 or Swagger 2 document",
+		"OpenAPI document in json or yaml", "OpenAPI document", core.ImportOpenAPI)
+	if err != nil {
+		return &comand, err
+	}
+	curl, err := newImportCommand("curl", "Generates hands from curl commands",
+		"file with curl commands", "curl commands", core.ImportCurl)
+	if err != nil {
+		return &comand, err
+	}
+	postman, err := newImportCommand("postman", "Generates hands from Postman v2.1 collection",
+		"Postman collection", "Postman collection", core.ImportPostman)
+	if err != nil {
+		return &comand, err
+	}
+	comand.AddCommand(openAPI, curl, postman)
+	parentCmd.AddCommand(&comand)
+	return &comand, nil
+}
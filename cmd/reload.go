@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	bot "github.com/wolf1996/HandWitch/pkg/bot"
+	"github.com/wolf1996/HandWitch/pkg/core"
+)
+
+// reloadDebounce изменения файлов собираются за это время, чтобы
+// не перезагружаться на каждую запись редактора
+const reloadDebounce = 500 * time.Millisecond
+
+// loadBotState грузим описания ручек и список пользователей,
+// возвращаем также пути файлов описаний для отслеживания
+func loadBotState(path string, whitelist string, logger *log.Logger) (*core.SimpleDescriptionsSource, bot.Authorisation, []string, error) {
+	auth, err := buildTelegramAuth(whitelist, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to load whitelist %w", err)
+	}
+
+	secrets, err := buildSecretsProvider()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to build secrets provider %w", err)
+	}
+
+	logger.Infof("Description file path used %s", path)
+	descriptions, paths, err := getDescriptionSourceFromPath(path, secrets, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to load descriptions %w", err)
+	}
+	return descriptions, auth, paths, nil
+}
+
+// reloader перезагружает описания бота, перезагрузки по SIGHUP и
+// от watcher выполняются по очереди, чтобы старые описания не
+// перетёрли более новые
+type reloader struct {
+	mutex     sync.Mutex
+	bot       *bot.Bot
+	app       core.URLProcessor
+	path      string
+	whitelist string
+	// watcher nil, если за файлами не следим
+	watcher *filesWatcher
+	logger  *log.Logger
+}
+
+// reload перечитываем описания ручек и список пользователей,
+// если они не прошли проверку, бот продолжает работать со старыми,
+// токены OAuth2 и кэш ответов переживают перезагрузку
+func (r *reloader) reload() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.logger.Info("Reloading descriptions and whitelist")
+	descriptions, auth, paths, err := loadBotState(r.path, r.whitelist, r.logger)
+	if err != nil {
+		r.logger.Errorf("Failed to reload, keep working with previous descriptions: %s", err.Error())
+		return
+	}
+	app := r.app.WithDescriptions(descriptions)
+	err = r.bot.Reload(app, auth)
+	if err != nil {
+		r.logger.Errorf("Failed to reload, keep working with previous descriptions: %s", err.Error())
+		return
+	}
+	r.app = app
+	if r.watcher != nil {
+		// после перезагрузки могли появиться новые include
+		r.watcher.addAll(paths, r.logger)
+	}
+	r.logger.Info("Descriptions and whitelist reloaded")
+}
+
+// notifyReload вызываем reload на каждый SIGHUP, пока контекст не отменён
+func notifyReload(ctx context.Context, reload func()) {
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(reloadSignals)
+		for {
+			select {
+			case <-reloadSignals:
+				reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// filesWatcher следит за файлами и директориями с их поддиректориями,
+// пути добавляются и при перезагрузке, поэтому они под мьютексом
+type filesWatcher struct {
+	watcher *fsnotify.Watcher
+	mutex   sync.Mutex
+	files   map[string]bool
+	dirs    []string
+}
+
+// add добавляем файл или директорию, за файлом следим через его
+// директорию, так как редакторы часто заменяют файл новым
+func (fw *filesWatcher) add(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	if fw.covered(path) {
+		return nil
+	}
+	if !info.IsDir() {
+		fw.files[path] = true
+		return fw.watcher.Add(filepath.Dir(path))
+	}
+	fw.dirs = append(fw.dirs, path)
+	return fw.addDir(path)
+}
+
+// addAll добавляем пути, подключённые через include, они могут
+// лежать вне отслеживаемых директорий
+func (fw *filesWatcher) addAll(paths []string, logger *log.Logger) {
+	for _, path := range paths {
+		err := fw.add(path)
+		if err != nil {
+			logger.Warnf("Failed to watch %s: %s", path, err.Error())
+		}
+	}
+}
+
+func (fw *filesWatcher) addDir(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return fw.watcher.Add(path)
+	})
+}
+
+// covered проверяем, отслеживается ли путь, вызывается под мьютексом
+func (fw *filesWatcher) covered(path string) bool {
+	if fw.files[path] {
+		return true
+	}
+	for _, dir := range fw.dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// relevant проверяем, относится ли событие к отслеживаемым путям
+func (fw *filesWatcher) relevant(event fsnotify.Event) bool {
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	return fw.covered(event.Name)
+}
+
+// newFilesWatcher начинаем следить за файлами по путям, события
+// обрабатываются после run, пути можно добавить позже через addAll
+func newFilesWatcher(paths []string) (*filesWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw := &filesWatcher{
+		watcher: watcher,
+		files:   make(map[string]bool),
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		err = fw.add(path)
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("Failed to watch %s: %w", path, err)
+		}
+	}
+	return fw, nil
+}
+
+// run вызываем reload при изменении отслеживаемых файлов, пока контекст не отменён
+func (fw *filesWatcher) run(ctx context.Context, reload func(), logger *log.Logger) {
+	watcher := fw.watcher
+	go func() {
+		defer watcher.Close()
+		timer := time.NewTimer(reloadDebounce)
+		timer.Stop()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !fw.relevant(event) {
+					continue
+				}
+				logger.Debugf("Got file event %s", event.String())
+				if event.Op&fsnotify.Create != 0 {
+					// новые поддиректории тоже отслеживаем
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := fw.addDir(event.Name); err != nil {
+							logger.Warnf("Failed to watch %s: %s", event.Name, err.Error())
+						}
+					}
+				}
+				timer.Reset(reloadDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warnf("Files watcher error %s", err.Error())
+			case <-timer.C:
+				reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
@@ -20,12 +20,13 @@ func initConfig(configPath string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("config path: %s\n", viper.ConfigFileUsed())
+	log.Infof("config path: %s", viper.ConfigFileUsed())
 	return nil
 }
 
-// prerunRoot парсим флаги и выставляем логлевлы и конфигурационные файлы
-func prerunRoot(cmd *cobra.Command, args []string) error {
+// prerunLog выставляем логлевл, логи пишутся в stderr и не
+// смешиваются с результатом команды
+func prerunLog(cmd *cobra.Command, args []string) error {
 	logLevel := cmd.Flag("log").Value.String()
 
 	loglevel, err := log.ParseLevel(logLevel)
@@ -33,28 +34,32 @@ func prerunRoot(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	log.SetLevel(loglevel)
+	return nil
+}
+
+// prerunRoot парсим флаги и выставляем логлевлы и конфигурационные файлы
+func prerunRoot(cmd *cobra.Command, args []string) error {
+	err := prerunLog(cmd, args)
+	if err != nil {
+		return err
+	}
 
 	// TODO: сделать дефолтный config path?
 	configPath := cmd.Flag("config").Value.String()
-	fmt.Printf("config: %s\n", configPath)
-	if configPath != "" {
-		err = initConfig(configPath)
-		if err != nil {
-			return err
-		}
+	if configPath == "" {
+		return errors.New("required flag(s) \"config\" not set")
 	}
-
-	return nil
+	return initConfig(configPath)
 }
 
-//buildSystemContext собираем контекст для корректной обработки сигналов системы
+//buildSystemContext собираем контекст для корректной обработки сигналов системы,
+// SIGHUP не останавливает бота, а перезагружает описания (см. notifyReload)
 func buildSystemContext(log *log.Logger) context.Context {
 	// Вешаем обработчики сигналов на контекст
 	ctx, cancel := context.WithCancel(context.Background())
-	sysSignals := make(chan os.Signal)
+	sysSignals := make(chan os.Signal, 1)
 
 	signal.Notify(sysSignals,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
@@ -84,17 +89,14 @@ func buildRootCmd() (*cobra.Command, error) {
 		Short:             "handwitch helps you to handle http request without frontend",
 	}
 	rootCmd.PersistentFlags().String("log", "info", "log level [info|warn|debug]")
+	// config обязателен для всех команд, кроме import и secrets, проверяется в prerunRoot
 	rootCmd.PersistentFlags().String("config", "", "configuration path file")
-	rootCmd.PersistentFlags().String("path", "", "descriptions file path")
+	rootCmd.PersistentFlags().String("path", "", "descriptions file or directory path")
 
 	err := rootCmd.MarkPersistentFlagFilename("config")
 	if err != nil {
 		return nil, err
 	}
-	err = rootCmd.MarkPersistentFlagRequired("config")
-	if err != nil {
-		return nil, err
-	}
 	err = rootCmd.MarkPersistentFlagFilename("path")
 	if err != nil {
 		return nil, err
@@ -121,5 +123,13 @@ func BuildAll() (*cobra.Command, error) {
 	if err != nil {
 		return nil, err
 	}
+	_, err = registerSecrets(rootCmd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = registerImport(rootCmd)
+	if err != nil {
+		return nil, err
+	}
 	return rootCmd, nil
 }
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wolf1996/HandWitch/pkg/core"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultSecretsKeyEnv переменная окружения с ключом зашифрованного файла секретов
+const defaultSecretsKeyEnv = "HANDWITCH_SECRETS_KEY"
+
+// getSecretsKey читаем ключ зашифрованного файла секретов из переменной окружения
+func getSecretsKey(keyEnv string) ([]byte, error) {
+	if keyEnv == "" {
+		keyEnv = defaultSecretsKeyEnv
+	}
+	encoded, ok := os.LookupEnv(keyEnv)
+	if !ok {
+		return nil, fmt.Errorf("Secrets key environment variable %s is not set", keyEnv)
+	}
+	return core.ParseSecretsKey(encoded)
+}
+
+// buildSecretsProvider собираем источник секретов для описаний ручек по конфигу
+func buildSecretsProvider() (core.SecretsProvider, error) {
+	provider := viper.GetString("secrets.provider")
+	switch provider {
+	case "", "env":
+		return core.EnvSecretsProvider{Prefix: viper.GetString("secrets.prefix")}, nil
+	case "dir":
+		dir := viper.GetString("secrets.dir")
+		if dir == "" {
+			return nil, fmt.Errorf("Secrets directory is not specified")
+		}
+		return core.DirectorySecretsProvider{Dir: dir}, nil
+	case "encrypted":
+		key, err := getSecretsKey(viper.GetString("secrets.key_env"))
+		if err != nil {
+			return nil, err
+		}
+		return core.NewEncryptedFileSecretsProvider(viper.GetString("secrets.file"), key)
+	}
+	return nil, fmt.Errorf("Unknown secrets provider %s", provider)
+}
+
+// encryptSecrets шифруем yaml файл с секретами
+func encryptSecrets(cmd *cobra.Command, args []string) error {
+	input := cmd.Flag("input").Value.String()
+	output := cmd.Flag("output").Value.String()
+	key, err := getSecretsKey(cmd.Flag("key-env").Value.String())
+	if err != nil {
+		return err
+	}
+	plain, err := ioutil.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("Failed to read secrets %w", err)
+	}
+	secrets := make(map[string]string)
+	err = yaml.Unmarshal(plain, &secrets)
+	if err != nil {
+		return fmt.Errorf("Failed to parse secrets %w", err)
+	}
+	encrypted, err := core.EncryptSecrets(secrets, key)
+	if err != nil {
+		return fmt.Errorf("Failed to encrypt secrets %w", err)
+	}
+	return ioutil.WriteFile(output, encrypted, 0600)
+}
+
+// generateSecretsKey печатаем новый ключ для шифрования секретов
+func generateSecretsKey(cmd *cobra.Command, args []string) error {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(key))
+	return nil
+}
+
+func registerSecrets(parentCmd *cobra.Command) (*cobra.Command, error) {
+	comand := cobra.Command{
+		Use:   "secrets",
+		Short: "Manages encrypted secrets file",
+		// ключ и шифрование не используют конфиг бота
+		PersistentPreRunE: prerunLog,
+	}
+	encrypt := cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypts yaml map of secrets",
+		RunE:  encryptSecrets,
+	}
+	encrypt.Flags().String("input", "", "plain yaml secrets file path")
+	encrypt.Flags().String("output", "secrets.enc", "encrypted secrets file path")
+	encrypt.Flags().String("key-env", defaultSecretsKeyEnv, "environment variable with encryption key")
+	err := encrypt.MarkFlagRequired("input")
+	if err != nil {
+		return &comand, err
+	}
+	key := cobra.Command{
+		Use:   "key",
+		Short: "Generates new encryption key",
+		RunE:  generateSecretsKey,
+	}
+	comand.AddCommand(&encrypt, &key)
+	parentCmd.AddCommand(&comand)
+	return &comand, nil
+}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -15,35 +14,10 @@ import (
 	"github.com/wolf1996/HandWitch/pkg/core"
 )
 
-func getDescriptionSourceFromFile(path string) (*core.URLProcessor, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	reader := bufio.NewReader(file)
-	ext := filepath.Ext(path)
-	var descriptionSource core.DescriptionsSource
-	switch ext {
-	case ".yaml":
-		descriptionSource, err = core.GetDescriptionSourceFromYAML(reader)
-		if err != nil {
-			return nil, err
-		}
-
-	case ".json":
-		descriptionSource, err = core.GetDescriptionSourceFromJSON(reader)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		return nil, fmt.Errorf("Unknown file extension %s", ext)
-	}
-	if err != nil {
-		return nil, err
-	}
-	processor := core.NewURLProcessor(descriptionSource, http.DefaultClient)
-	//TODO: попробовать поправить ссылки и интерфейсы
-	return &processor, nil
+// getDescriptionSourceFromPath грузим описания ручек из файла или директории,
+// возвращаем также пути всех подключённых через include файлов
+func getDescriptionSourceFromPath(path string, secrets core.SecretsProvider, logger *log.Logger) (*core.SimpleDescriptionsSource, []string, error) {
+	return core.LoadDescriptionsAndPaths(path, secrets, log.NewEntry(logger))
 }
 
 func getAuthSourceFromFile(path string) (bot.Authorisation, error) {
@@ -169,19 +143,13 @@ func exec(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	auth, err := buildTelegramAuth(whitelist, logger)
-	if err != nil {
-		logger.Errorf("Failed to load whitelist %s", err.Error())
-		return nil
-	}
-
-	// грузим описания ручек
-	log.Infof("Description file path used %s", path)
-	urlContainer, err := getDescriptionSourceFromFile(path)
+	descriptions, auth, descriptionPaths, err := loadBotState(path, whitelist, logger)
 	if err != nil {
-		logger.Errorf("Failed to get description source file %s", err.Error())
+		logger.Errorf("%s", err.Error())
 		return nil
 	}
+	app := core.NewURLProcessor(descriptions, http.DefaultClient)
+	//TODO: попробовать поправить ссылки и интерфейсы
 
 	log.Info("Creating telegram bot api client")
 
@@ -192,7 +160,7 @@ func exec(cmd *cobra.Command, args []string) error {
 		hookConfig = nil
 	}
 
-	botInstance, err := bot.NewBot(httpClient, token, *urlContainer, auth, formating, hookConfig)
+	botInstance, err := bot.NewBot(httpClient, token, app, auth, formating, hookConfig)
 	if err != nil {
 		logger.Errorf("Failed to create bot %s", err.Error())
 		return nil
@@ -201,6 +169,25 @@ func exec(cmd *cobra.Command, args []string) error {
 	log.Info("Telegram bot api client created")
 
 	ctx := buildSystemContext(logger)
+	botReloader := &reloader{
+		bot:       botInstance,
+		app:       app,
+		path:      path,
+		whitelist: whitelist,
+		logger:    logger,
+	}
+	if viper.GetBool("watch") {
+		// watcher создаётся до запуска, reload может его дополнить
+		botReloader.watcher, err = newFilesWatcher([]string{path, whitelist})
+		if err != nil {
+			logger.Errorf("Failed to watch files %s", err.Error())
+			return nil
+		}
+		botReloader.watcher.addAll(descriptionPaths, logger)
+		botReloader.watcher.run(ctx, botReloader.reload, logger)
+		logger.Info("Watching descriptions and whitelist for changes")
+	}
+	notifyReload(ctx, botReloader.reload)
 
 	err = botInstance.Listen(ctx, logger)
 	if err != nil {
@@ -220,6 +207,7 @@ func registerServeBot(parentCmd *cobra.Command) (*cobra.Command, error) {
 	comand.PersistentFlags().String("whitelist", "", "configuration path file")
 	comand.PersistentFlags().String("formatting", "", "formatting mode of telegramm message")
 	comand.PersistentFlags().String("tgproxy", "", "proxy to telegram client")
+	comand.PersistentFlags().Bool("watch", false, "reload descriptions and whitelist when files change")
 
 	err := comand.MarkPersistentFlagRequired("token")
 	if err != nil {
@@ -238,6 +226,10 @@ func registerServeBot(parentCmd *cobra.Command) (*cobra.Command, error) {
 	if err != nil {
 		return &comand, err
 	}
+	err = bindFlag(&comand, "watch", "watch")
+	if err != nil {
+		return &comand, err
+	}
 	parentCmd.AddCommand(&comand)
 	return &comand, nil
 }
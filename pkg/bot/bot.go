@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"context"
 
@@ -41,11 +42,17 @@ type HookConfig struct {
 	Key     string
 }
 
+// botState описания ручек и список пользователей, которые подменяются при перезагрузке
+type botState struct {
+	app  core.URLProcessor
+	auth Authorisation
+}
+
 // Bot создаёт общий интерфейс для бота
 type Bot struct {
-	api        *tgbotapi.BotAPI
-	app        core.URLProcessor
-	auth       Authorisation
+	api *tgbotapi.BotAPI
+	// state текущий *botState, начатые запросы продолжают работать со старым
+	state      atomic.Value
 	formating  string
 	processing inProgresTask
 	cmds       map[string]comandFabric
@@ -67,20 +74,51 @@ func NewBot(client *http.Client, token string, app core.URLProcessor, auth Autho
 	cmds["process"] = newProcessCommand
 	cmds["help"] = newHelpCommand
 	cmds["start"] = newStartCommand
-	return &Bot{
+	result := &Bot{
 		api:        bot,
-		app:        app,
-		auth:       auth,
 		formating:  normalizedMessageMode,
 		processing: make(inProgresTask),
 		cmds:       cmds,
 		hookCfg:    hookCfg,
-	}, nil
+	}
+	err = result.Reload(app, auth)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Reload атомарно подменяем описания ручек и список пользователей,
+// уже начатые запросы дорабатывают со старыми описаниями
+func (b *Bot) Reload(app core.URLProcessor, auth Authorisation) error {
+	// values in hands output are escaped for used formating
+	err := app.SetParseMode(core.ParseMode(b.formating))
+	if err != nil {
+		return fmt.Errorf("Invalid formating %w", err)
+	}
+	b.state.Store(&botState{
+		app:  app,
+		auth: auth,
+	})
+	return nil
+}
+
+// current текущие описания ручек и список пользователей
+func (b *Bot) current() *botState {
+	return b.state.Load().(*botState)
 }
 
 func (b *Bot) processCmd(ctx context.Context, messageArguments string, message *tgbotapi.Message, input messagesChan, fabric comandFabric, logger *log.Entry) error {
 	tg := newWrapper(input, b.api, message, b.formating, logger)
-	command := fabric(ctx, b.app, tg, logger)
+	if message.From != nil {
+		ctx = core.WithUser(ctx, core.User{
+			ID:        message.From.ID,
+			Login:     message.From.UserName,
+			FirstName: message.From.FirstName,
+			LastName:  message.From.LastName,
+		})
+	}
+	command := fabric(ctx, b.current().app, tg, logger)
 	return command.Process(messageArguments)
 }
 
@@ -119,7 +157,7 @@ func (b *Bot) newHandleMessage(ctx context.Context, message *tgbotapi.Message, i
 }
 
 func (b *Bot) checkMessageAuth(message *tgbotapi.Message) (bool, error) {
-	role, err := b.auth.GetRoleByLogin(message.From.UserName)
+	role, err := b.current().auth.GetRoleByLogin(message.From.UserName)
 	if err != nil {
 		return false, err
 	}
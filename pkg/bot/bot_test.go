@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/wolf1996/HandWitch/pkg/core"
+)
+
+type loginsAuthorisation map[string]Role
+
+func (auth loginsAuthorisation) GetRoleByLogin(login string) (Role, error) {
+	role, ok := auth[login]
+	if !ok {
+		return Guest, ErrUserNotFound
+	}
+	return role, nil
+}
+
+func buildProcessor(t *testing.T, name string) core.URLProcessor {
+	description := name + `:
+  url_template: http://localhost/` + name + `
+  body: ok
+  url_name: ` + name + `
+  help: ""`
+	source, err := core.GetDescriptionSourceFromYAML(strings.NewReader(description))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return core.NewURLProcessor(source, http.DefaultClient)
+}
+
+func TestReload(t *testing.T) {
+	// проверяем подмену описаний и списка пользователей
+	b := &Bot{formating: "Markdown"}
+	err := b.Reload(buildProcessor(t, "old"), loginsAuthorisation{"alice": User})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	oldState := b.current()
+
+	err = b.Reload(buildProcessor(t, "new"), loginsAuthorisation{"bob": User})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	state := b.current()
+	if _, err = state.app.GetHand("new"); err != nil {
+		t.Errorf("expected new hand after reload got %s", err.Error())
+	}
+	if _, err = state.app.GetHand("old"); err == nil {
+		t.Errorf("expected old hand to be removed after reload")
+	}
+	if _, err = state.auth.GetRoleByLogin("alice"); err == nil {
+		t.Errorf("expected alice to be removed from whitelist")
+	}
+
+	// начатые запросы продолжают работать со старыми описаниями
+	if _, err = oldState.app.GetHand("old"); err != nil {
+		t.Errorf("expected old hand in previous state got %s", err.Error())
+	}
+
+	// при ошибке текущие описания не меняются
+	b.formating = "Unknown"
+	err = b.Reload(buildProcessor(t, "broken"), DummyAuthorisation{})
+	if err == nil {
+		t.Errorf("expected error on invalid formating")
+	}
+	if b.current() != state {
+		t.Errorf("expected state to be kept on failed reload")
+	}
+}
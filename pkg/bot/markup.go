@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// parseErrorContext number of bytes around failed offset shown in logs
+const parseErrorContext = 20
+
+var (
+	parseErrorOffsetRe = regexp.MustCompile(`byte offset (\d+)`)
+	htmlTagRe          = regexp.MustCompile(`<[^>]*>`)
+	markdownLinkRe     = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+	markdownEscapedRe  = regexp.MustCompile("\\\\([_*\\[\\]()~`>#+\\-=|{}.!\\\\])")
+	markdownMarkupRe   = regexp.MustCompile("[*_~`]")
+)
+
+// isParseEntitiesError check if telegram rejected message because of broken markup
+func isParseEntitiesError(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "can't parse entities")
+}
+
+// parseErrorOffset get byte offset of broken markup from telegram error
+func parseErrorOffset(err error) (int, bool) {
+	match := parseErrorOffsetRe.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+	offset, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return 0, false
+	}
+	return offset, true
+}
+
+// markupContext part of message around offset to find broken markup
+func markupContext(text string, offset int) string {
+	begin := offset - parseErrorContext
+	if begin < 0 {
+		begin = 0
+	}
+	end := offset + parseErrorContext
+	if end > len(text) {
+		end = len(text)
+	}
+	if begin > end {
+		return ""
+	}
+	return strings.ToValidUTF8(text[begin:end], "")
+}
+
+// stripMarkup remove markup of formating from message text
+func stripMarkup(text string, formating string) string {
+	switch formating {
+	case tgbotapi.ModeHTML:
+		return html.UnescapeString(htmlTagRe.ReplaceAllString(text, ""))
+	case tgbotapi.ModeMarkdown, "MarkdownV2":
+		// escaped characters are hidden from markup removal with placeholders
+		escaped := make([]string, 0)
+		text = markdownEscapedRe.ReplaceAllStringFunc(text, func(match string) string {
+			escaped = append(escaped, match[1:])
+			return "\x00"
+		})
+		text = markdownLinkRe.ReplaceAllString(text, "$1 ($2)")
+		text = markdownMarkupRe.ReplaceAllString(text, "")
+		for _, value := range escaped {
+			text = strings.Replace(text, "\x00", value, 1)
+		}
+		return text
+	}
+	return text
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func TestStripMarkup(t *testing.T) {
+	testCases := []struct {
+		Formating string
+		Text      string
+		Result    string
+	}{
+		{
+			Formating: tgbotapi.ModeHTML,
+			Text:      `<b>Name:</b> <a href="http://x">a &amp; b</a> &lt;c&gt; <i>broken`,
+			Result:    "Name: a & b <c> broken",
+		},
+		{
+			Formating: tgbotapi.ModeMarkdown,
+			Text:      "*bold* _it_ `code` [link](http://x) a\\_b *broken",
+			Result:    "bold it code link (http://x) a_b broken",
+		},
+		{
+			Formating: "MarkdownV2",
+			Text:      `*1\.5* \(x\)`,
+			Result:    "1.5 (x)",
+		},
+		{
+			Formating: "",
+			Text:      "*as is*",
+			Result:    "*as is*",
+		},
+	}
+	for _, testCase := range testCases {
+		result := stripMarkup(testCase.Text, testCase.Formating)
+		if result != testCase.Result {
+			t.Errorf("%s: expected %q got %q", testCase.Formating, testCase.Result, result)
+		}
+	}
+}
+
+func TestParseEntitiesError(t *testing.T) {
+	err := tgbotapi.Error{Message: "Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 12"}
+	if !isParseEntitiesError(err) {
+		t.Errorf("parse error is not detected")
+	}
+	if isParseEntitiesError(errors.New("Forbidden: bot was blocked by the user")) {
+		t.Errorf("unexpected parse error")
+	}
+	offset, ok := parseErrorOffset(err)
+	if !ok || offset != 12 {
+		t.Errorf("expected offset 12 got %d %v", offset, ok)
+	}
+	context := markupContext("0123456789*broken markup here", offset)
+	if context != "0123456789*broken markup here" {
+		t.Errorf("unexpected context %q", context)
+	}
+}
@@ -27,6 +27,7 @@ type baseState struct {
 	logger        *log.Entry
 	ctx           context.Context
 	handProcessor core.HandProcessor
+	urlProc       core.URLProcessor
 	tg            telegram
 }
 
@@ -76,7 +77,7 @@ func (st *startState) Do() (processingState, error) {
 			}
 			continue
 		}
-		params[name] = val
+		st.setValue(params, name, val)
 	}
 	return &inqueryParamsState{
 		st.baseState,
@@ -98,6 +99,9 @@ func parseParamRow(handProcessor core.HandProcessor, messageRow string) (string,
 	if err != nil {
 		return "", nil, err
 	}
+	if paramProcessor.GetInfo().Computed != "" {
+		return "", nil, fmt.Errorf("Param %s is computed and can't be set", paramName)
+	}
 	value, err := paramProcessor.ParseFromString(paramValueStr)
 	if err != nil {
 		return "", nil, err
@@ -131,23 +135,27 @@ func (st *inqueryParamsState) helpCommand(msg string) (processingState, error) {
 	return nil, nil
 }
 
-func (st *inqueryParamsState) Do() (processingState, error) {
-	requiredParams, err := st.handProcessor.GetRequiredParams()
+// missingParams get required params without values, params
+// requirement depends on values of other params
+func (st *inqueryParamsState) missingParams() (map[string]core.ParamProcessor, error) {
+	requiredParams, err := st.handProcessor.GetRequiredParamsFor(st.params)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get hand required parameters: %w", err)
 	}
-
-	getMissingParams := func() map[string]core.ParamProcessor {
-		missingParams := make(map[string]core.ParamProcessor)
-		for _, param := range requiredParams {
-			if _, ok := st.params[param.GetInfo().Name]; !ok {
-				missingParams[param.GetInfo().Name] = param
-			}
+	missingParams := make(map[string]core.ParamProcessor)
+	for _, param := range requiredParams {
+		if _, ok := st.params[param.GetInfo().Name]; !ok {
+			missingParams[param.GetInfo().Name] = param
 		}
-		return missingParams
 	}
+	return missingParams, nil
+}
 
-	missingParams := getMissingParams()
+func (st *inqueryParamsState) Do() (processingState, error) {
+	missingParams, err := st.missingParams()
+	if err != nil {
+		return nil, err
+	}
 
 	cmdProcessors := buttonRouters{
 		func(msg string) (processingState, error) {
@@ -160,7 +168,7 @@ func (st *inqueryParamsState) Do() (processingState, error) {
 			return state, err
 		},
 		func(msg string) (processingState, error) {
-			params, err := st.handProcessor.GetParams()
+			params, err := st.handProcessor.GetVisibleParams(st.params)
 			if err != nil {
 				st.logger.Errorf("Failed to get params for hand")
 				return nil, err
@@ -218,7 +226,7 @@ func (st *inqueryParamsState) Do() (processingState, error) {
 	}
 	// TODO: задуматься! кажется я делаю что-то не так!
 	for {
-		paramsProcessors, err := st.handProcessor.GetParams()
+		paramsProcessors, err := st.handProcessor.GetVisibleParams(st.params)
 		if err != nil {
 			return nil, fmt.Errorf("failed request parameters from user %w", err)
 		}
@@ -253,8 +261,68 @@ func (st *inqueryParamsState) Do() (processingState, error) {
 
 //-------------------------------------------- queryParam states methods -------------------------------------------------------
 
+func (st *queryParam) requestValue(options []core.ParamOption, page int) error {
+	if len(options) == 0 {
+		return st.tg.Send(st.ctx, fmt.Sprintf("Input value for param: \"%s\"", st.paramProcessor.GetInfo().Name))
+	}
+	return st.tg.RequestValue(st.paramProcessor.GetInfo().Name, options, page)
+}
+
+// loadOptions get values offered to user, options loading
+// failure is reported and user is asked for free text input
+func (st *queryParam) loadOptions() ([]core.ParamOption, error) {
+	name := st.paramProcessor.GetInfo().Name
+	options, err := st.urlProc.GetParamOptions(st.ctx, st.handProcessor.GetInfo().URLName, name, st.params, st.logger)
+	if err != nil {
+		st.logger.Warnf("Failed to load options of param %s: %s", name, err.Error())
+		err = st.tg.Send(st.ctx, fmt.Sprintf("Failed to load options for param \"%s\": %s", name, err.Error()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to send error message to user %w", err)
+		}
+		return nil, nil
+	}
+	return options, nil
+}
+
+// setValue set param value, values of params depending
+// on the changed param are reset to be entered again
+func (st *baseState) setValue(params map[string]interface{}, name string, value interface{}) {
+	previous, hadValue := params[name]
+	params[name] = value
+	if !hadValue || fmt.Sprint(previous) == fmt.Sprint(value) {
+		return
+	}
+	st.resetDependents(params, name)
+}
+
+// resetDependents remove values of params depending on the param
+func (st *baseState) resetDependents(params map[string]interface{}, name string) {
+	processors, err := st.handProcessor.GetParams()
+	if err != nil {
+		st.logger.Warnf("Failed to get params for hand %s", err.Error())
+		return
+	}
+	for dependentName, param := range processors {
+		for _, dependency := range param.GetInfo().DependsOn {
+			if dependency != name {
+				continue
+			}
+			if _, ok := params[dependentName]; ok {
+				delete(params, dependentName)
+				st.logger.Debugf("Reset param %s depending on %s", dependentName, name)
+				st.resetDependents(params, dependentName)
+			}
+		}
+	}
+}
+
 func (st *queryParam) Do() (processingState, error) {
-	err := st.tg.Send(st.ctx, fmt.Sprintf("Input value for param: \"%s\"", st.paramProcessor.GetInfo().Name))
+	options, err := st.loadOptions()
+	if err != nil {
+		return nil, err
+	}
+	page := 0
+	err = st.requestValue(options, page)
 	if err != nil {
 		//TODO проверить обработку ошибок и ретраи
 		return nil, fmt.Errorf("failed request missing parameters from user %w", err)
@@ -265,17 +333,35 @@ LOOP:
 		if err != nil {
 			continue LOOP
 		}
-		value, err := st.paramProcessor.ParseFromString(inp)
-		if err != nil {
-			err = st.tg.Send(st.ctx, fmt.Sprintf("Failed to parse param:  %s", err.Error()))
+		switch {
+		case inp == PrevPageButtonContent && page > 0:
+			page--
+		case inp == NextPageButtonContent && page+1 < optionsPagesCount(options):
+			page++
+		default:
+			value, err := st.paramProcessor.ParseFromString(optionValue(options, inp))
 			if err != nil {
-				return nil, fmt.Errorf("Failed to send error message to user %w", err)
+				err = st.tg.Send(st.ctx, fmt.Sprintf("Failed to parse param:  %s", err.Error()))
+				if err != nil {
+					return nil, fmt.Errorf("Failed to send error message to user %w", err)
+				}
+				if len(options) != 0 {
+					// error message hides options keyboard
+					err = st.requestValue(options, page)
+					if err != nil {
+						return nil, fmt.Errorf("failed request missing parameters from user %w", err)
+					}
+				}
+				continue LOOP
 			}
-			continue LOOP
+			delete(st.missingParams, st.paramProcessor.GetInfo().Name)
+			st.setValue(st.params, st.paramProcessor.GetInfo().Name, value)
+			break LOOP
+		}
+		err = st.requestValue(options, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed request missing parameters from user %w", err)
 		}
-		delete(st.missingParams, st.paramProcessor.GetInfo().Name)
-		st.params[st.paramProcessor.GetInfo().Name] = value
-		break
 	}
 
 	return &inqueryParamsState{
@@ -334,6 +420,7 @@ func (proc *processCommand) Process(messageArguments string) error {
 			ctx:           proc.ctx,
 			logger:        proc.log,
 			handProcessor: handProc,
+			urlProc:       proc.urlProc,
 			tg:            proc.tg,
 		},
 		arguments: messageArguments,
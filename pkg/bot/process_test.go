@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/wolf1996/HandWitch/pkg/core"
+)
+
+// scriptedTelegram отвечает заранее заданными сообщениями и запоминает отправленные
+type scriptedTelegram struct {
+	input []string
+	sent  []string
+}
+
+func (tg *scriptedTelegram) Get(ctx context.Context) (message, error) {
+	if len(tg.input) == 0 {
+		return "", errors.New("no more input")
+	}
+	msg := tg.input[0]
+	tg.input = tg.input[1:]
+	return msg, nil
+}
+
+func (tg *scriptedTelegram) Send(ctx context.Context, msg string) error {
+	tg.sent = append(tg.sent, msg)
+	return nil
+}
+
+func (tg *scriptedTelegram) RequestParams(missingParams map[string]core.ParamProcessor, params map[string]core.ParamProcessor, values map[string]interface{}, buttons []ExtraButton) error {
+	return nil
+}
+
+func (tg *scriptedTelegram) RequestValue(name string, options []core.ParamOption, page int) error {
+	return nil
+}
+
+func TestDependentParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "tenant=%s deployment=%s", r.URL.Query().Get("tenant"), r.URL.Query().Get("deployment"))
+	}))
+	defer server.Close()
+
+	description := `deploy:
+  url_template: ` + server.URL + `/deploy
+  body: "{{ .responce }}"
+  url_name: deploy
+  help: ""
+  parameters:
+    tenant:
+      name: tenant
+      type: string
+      destination: query
+      optional: true
+    deployment:
+      name: deployment
+      type: string
+      destination: query
+      depends_on: [tenant]`
+	source, err := core.GetDescriptionSourceFromYAML(strings.NewReader(description))
+	if err != nil {
+		t.Fatal(err)
+	}
+	processor := core.NewURLProcessor(source, server.Client())
+
+	testCases := []struct {
+		Name      string
+		Arguments string
+		Input     []string
+		Result    string
+	}{
+		{
+			// обязательный параметр не требуется, пока не задан необязательный параметр, от которого он зависит
+			Name:      "optional dependency unset",
+			Arguments: "deploy",
+			Input:     []string{OkButtonContent},
+			Result:    "tenant= deployment=",
+		},
+		{
+			// смена значения сбрасывает зависимые параметры, их нужно ввести заново
+			Name:      "dependency changed",
+			Arguments: "deploy\ntenant us\ndeployment api",
+			Input:     []string{"tenant", "eu", OkButtonContent, "deployment", "web", OkButtonContent},
+			Result:    "tenant=eu deployment=web",
+		},
+		{
+			// то же значение не сбрасывает зависимые параметры
+			Name:      "dependency kept",
+			Arguments: "deploy\ntenant us\ndeployment api\ntenant us",
+			Input:     []string{OkButtonContent},
+			Result:    "tenant=us deployment=api",
+		},
+	}
+	for _, testCase := range testCases {
+		tg := &scriptedTelegram{input: testCase.Input}
+		command := newProcessCommand(context.Background(), processor, tg, log.NewEntry(log.StandardLogger()))
+		err := command.Process(testCase.Arguments)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		if len(tg.sent) == 0 || tg.sent[len(tg.sent)-1] != testCase.Result {
+			t.Errorf("%s: expected result %q got messages %q", testCase.Name, testCase.Result, tg.sent)
+		}
+	}
+}
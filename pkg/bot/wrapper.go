@@ -3,6 +3,7 @@ package bot
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
@@ -31,6 +32,17 @@ const (
 	OkButtonContent = "🤖 Start!"
 	// CancelButtonContent data of string in Cancel button
 	CancelButtonContent = "🤖 cancel"
+	// PrevPageButtonContent data of string in previous options page button
+	PrevPageButtonContent = "🤖 ◀"
+	// NextPageButtonContent data of string in next options page button
+	NextPageButtonContent = "🤖 ▶"
+)
+
+const (
+	// keyboardRowSize number of buttons in keyboard row
+	keyboardRowSize = 2
+	// optionsPageSize number of param options on one keyboard page
+	optionsPageSize = 10
 )
 
 type (
@@ -42,6 +54,7 @@ type telegram interface {
 	Get(ctx context.Context) (message, error)
 	Send(ctx context.Context, msg string) error
 	RequestParams(missingParams map[string]core.ParamProcessor, params map[string]core.ParamProcessor, values map[string]interface{}, buttons []ExtraButton) error
+	RequestValue(name string, options []core.ParamOption, page int) error
 }
 
 type wrapper struct {
@@ -88,6 +101,17 @@ func (wp *wrapper) Send(ctx context.Context, msgTxt string) error {
 	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
 
 	_, err := wp.api.Send(msg)
+	if isParseEntitiesError(err) && msg.ParseMode != "" {
+		offset, ok := parseErrorOffset(err)
+		if ok {
+			wp.logger.Errorf("Telegram failed to parse %s message at byte offset %d near %q: %s, sending as plain text", msg.ParseMode, offset, markupContext(msg.Text, offset), err.Error())
+		} else {
+			wp.logger.Errorf("Telegram failed to parse %s message: %s, sending as plain text", msg.ParseMode, err.Error())
+		}
+		msg.Text = stripMarkup(msg.Text, msg.ParseMode)
+		msg.ParseMode = ""
+		_, err = wp.api.Send(msg)
+	}
 	if err != nil {
 		wp.logger.Errorf("Error on sending message %s:\n message text:\n %s", err.Error(), msg.Text)
 		return err
@@ -95,19 +119,30 @@ func (wp *wrapper) Send(ctx context.Context, msgTxt string) error {
 	return nil
 }
 
-func buildKeyboard(missingParams map[string]core.ParamProcessor, buttonsDescriptions []ExtraButton) ([][]tgbotapi.KeyboardButton, error) {
-	buttons := make([][]tgbotapi.KeyboardButton, 0)
-	const rowSize = 2
-	buttonsRow := make([]tgbotapi.KeyboardButton, 0)
+// arrangeButtons place buttons into rows of keyboardRowSize
+func arrangeButtons(labels []string) [][]tgbotapi.KeyboardButton {
+	rows := make([][]tgbotapi.KeyboardButton, 0)
+	for begin := 0; begin < len(labels); begin += keyboardRowSize {
+		end := begin + keyboardRowSize
+		if end > len(labels) {
+			end = len(labels)
+		}
+		row := make([]tgbotapi.KeyboardButton, 0, keyboardRowSize)
+		for _, label := range labels[begin:end] {
+			row = append(row, tgbotapi.NewKeyboardButton(label))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
 
+func buildKeyboard(missingParams map[string]core.ParamProcessor, buttonsDescriptions []ExtraButton) ([][]tgbotapi.KeyboardButton, error) {
+	paramNames := make([]string, 0, len(missingParams))
 	for paramName := range missingParams {
-		paramButton := tgbotapi.NewKeyboardButton(paramName)
-		buttonsRow = append(buttonsRow, paramButton)
-		if len(buttonsRow) == rowSize {
-			buttons = append(buttons, buttonsRow)
-			buttonsRow = buttonsRow[:0]
-		}
+		paramNames = append(paramNames, paramName)
 	}
+	sort.Strings(paramNames)
+	buttons := arrangeButtons(paramNames)
 
 	additionalButtons, err := getCustomButtons(buttonsDescriptions)
 	if err != nil {
@@ -190,3 +225,66 @@ func (wp *wrapper) RequestParams(missingParams map[string]core.ParamProcessor, p
 	}
 	return nil
 }
+
+// optionsPage get options shown on keyboard page
+func optionsPage(options []core.ParamOption, page int) (pageOptions []core.ParamOption, hasPrev bool, hasNext bool) {
+	begin := page * optionsPageSize
+	if begin > len(options) {
+		begin = len(options)
+	}
+	end := begin + optionsPageSize
+	if end > len(options) {
+		end = len(options)
+	}
+	return options[begin:end], page > 0, end < len(options)
+}
+
+// optionsPagesCount get number of keyboard pages with options
+func optionsPagesCount(options []core.ParamOption) int {
+	return (len(options) + optionsPageSize - 1) / optionsPageSize
+}
+
+func buildOptionsKeyboard(options []core.ParamOption, page int) [][]tgbotapi.KeyboardButton {
+	pageOptions, hasPrev, hasNext := optionsPage(options, page)
+	labels := make([]string, 0, len(pageOptions))
+	for _, option := range pageOptions {
+		labels = append(labels, option.GetLabel())
+	}
+	rows := arrangeButtons(labels)
+	navigation := make([]tgbotapi.KeyboardButton, 0)
+	if hasPrev {
+		navigation = append(navigation, tgbotapi.NewKeyboardButton(PrevPageButtonContent))
+	}
+	if hasNext {
+		navigation = append(navigation, tgbotapi.NewKeyboardButton(NextPageButtonContent))
+	}
+	if len(navigation) != 0 {
+		rows = append(rows, navigation)
+	}
+	return rows
+}
+
+// optionValue get value of option chosen by it's label,
+// input is returned as is if it's not an option label
+func optionValue(options []core.ParamOption, input string) string {
+	for _, option := range options {
+		if option.GetLabel() == input {
+			return option.Value
+		}
+	}
+	return input
+}
+
+func (wp *wrapper) RequestValue(name string, options []core.ParamOption, page int) error {
+	text := fmt.Sprintf("Choose value for param: \"%s\"", name)
+	if pages := optionsPagesCount(options); pages > 1 {
+		text += fmt.Sprintf(" (page %d of %d)", page+1, pages)
+	}
+	msg := tgbotapi.NewMessage(wp.chat.ID, text)
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(buildOptionsKeyboard(options, page)...)
+	_, err := wp.api.Send(msg)
+	if err != nil {
+		return fmt.Errorf("failed request param value from user %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wolf1996/HandWitch/pkg/core"
+)
+
+func keyboardText(options []core.ParamOption, page int) [][]string {
+	rows := make([][]string, 0)
+	for _, row := range buildOptionsKeyboard(options, page) {
+		texts := make([]string, 0)
+		for _, button := range row {
+			texts = append(texts, button.Text)
+		}
+		rows = append(rows, texts)
+	}
+	return rows
+}
+
+func TestOptionsKeyboard(t *testing.T) {
+	options := make([]core.ParamOption, 0)
+	for i := 0; i < optionsPageSize+3; i++ {
+		options = append(options, core.ParamOption{Value: fmt.Sprintf("v%d", i)})
+	}
+	options[0].Label = "first"
+
+	if optionsPagesCount(options) != 2 {
+		t.Errorf("expected 2 pages got %d", optionsPagesCount(options))
+	}
+	firstPage := keyboardText(options, 0)
+	if len(firstPage) != optionsPageSize/keyboardRowSize+1 {
+		t.Errorf("unexpected first page rows %v", firstPage)
+	}
+	if firstPage[0][0] != "first" || firstPage[0][1] != "v1" {
+		t.Errorf("unexpected first row %v", firstPage[0])
+	}
+	if fmt.Sprint(firstPage[len(firstPage)-1]) != fmt.Sprint([]string{NextPageButtonContent}) {
+		t.Errorf("expected only next page button got %v", firstPage[len(firstPage)-1])
+	}
+
+	lastPage := keyboardText(options, 1)
+	expected := fmt.Sprint([][]string{{"v10", "v11"}, {"v12"}, {PrevPageButtonContent}})
+	if fmt.Sprint(lastPage) != expected {
+		t.Errorf("expected last page %s got %v", expected, lastPage)
+	}
+
+	if optionValue(options, "first") != "v0" || optionValue(options, "typed") != "typed" {
+		t.Errorf("unexpected option values")
+	}
+}
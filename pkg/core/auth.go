@@ -0,0 +1,370 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthType upstream authentication scheme
+type AuthType string
+
+const (
+	//BasicAuth login and password sent with basic auth
+	BasicAuth AuthType = "basic"
+	//BearerAuth static token sent in Authorization header
+	BearerAuth AuthType = "bearer"
+	//APIKeyAuth static key sent as a header or a query parameter
+	APIKeyAuth AuthType = "api_key"
+	//OAuth2Auth token got with OAuth2 client credentials flow
+	OAuth2Auth AuthType = "oauth2"
+)
+
+const (
+	// tokenRefreshMargin token is refreshed this time before expiration
+	tokenRefreshMargin = 30 * time.Second
+	// defaultTokenLifetime used if token server didn't report expiration
+	defaultTokenLifetime = time.Minute
+)
+
+var (
+	// ErrNoSecretSource secret has neither env nor file source
+	ErrNoSecretSource = errors.New("Secret source is not specified")
+)
+
+// SecretValue reference to secret stored out of descriptions
+// secret can be read from environment variable, from file or
+// by name from secrets provider like ${NAME} references
+type SecretValue struct {
+	Env  string `json:"env" yaml:"env,omitempty"`
+	File string `json:"file" yaml:"file,omitempty"`
+	Name string `json:"name" yaml:"name,omitempty"`
+	// value secret value resolved on loading descriptions
+	value    string
+	resolved bool
+}
+
+// IsEmpty check if secret source is specified
+func (secret *SecretValue) IsEmpty() bool {
+	return secret.Env == "" && secret.File == "" && secret.Name == ""
+}
+
+// Resolve get secret value resolved on loading descriptions, secret
+// of description which isn't loaded is read from it's source,
+// secrets with name are taken from environment then
+func (secret *SecretValue) Resolve() (string, error) {
+	if secret.resolved {
+		return secret.value, nil
+	}
+	return secret.resolveWith(EnvSecretsProvider{})
+}
+
+// resolveWith read secret value from it's source, secrets
+// with name are taken from secrets provider
+func (secret *SecretValue) resolveWith(secrets SecretsProvider) (string, error) {
+	if secret.Name != "" {
+		value, err := secrets.GetSecret(secret.Name)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", secret.Name, err)
+		}
+		return value, nil
+	}
+	if secret.Env != "" {
+		value, ok := os.LookupEnv(secret.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", secret.Env)
+		}
+		return value, nil
+	}
+	if secret.File != "" {
+		value, err := ioutil.ReadFile(secret.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file %s: %w", secret.File, err)
+		}
+		return strings.TrimSpace(string(value)), nil
+	}
+	return "", ErrNoSecretSource
+}
+
+// AuthInfo upstream authentication description
+type AuthInfo struct {
+	Type         AuthType         `json:"type" yaml:"type,omitempty"`
+	Username     string           `json:"username" yaml:"username,omitempty"`
+	Password     SecretValue      `json:"password" yaml:"password,omitempty"`
+	Token        SecretValue      `json:"token" yaml:"token,omitempty"`
+	Key          SecretValue      `json:"key" yaml:"key,omitempty"`
+	Name         string           `json:"name" yaml:"name,omitempty"`
+	In           ParamDestination `json:"in" yaml:"in,omitempty"`
+	TokenURL     string           `json:"token_url" yaml:"token_url,omitempty"`
+	ClientID     string           `json:"client_id" yaml:"client_id,omitempty"`
+	ClientSecret SecretValue      `json:"client_secret" yaml:"client_secret,omitempty"`
+	Scopes       []string         `json:"scopes" yaml:"scopes,omitempty"`
+}
+
+// namedSecret secret of auth description with it's field name
+type namedSecret struct {
+	name   string
+	secret *SecretValue
+}
+
+// secrets declared secrets of auth description
+func (auth *AuthInfo) secrets() []namedSecret {
+	all := []namedSecret{
+		{name: "password", secret: &auth.Password},
+		{name: "token", secret: &auth.Token},
+		{name: "key", secret: &auth.Key},
+		{name: "client_secret", secret: &auth.ClientSecret},
+	}
+	declared := make([]namedSecret, 0, len(all))
+	for _, named := range all {
+		if !named.secret.IsEmpty() {
+			declared = append(declared, named)
+		}
+	}
+	return declared
+}
+
+// resolveAuthSecrets resolve declared auth secrets with secrets provider
+// on loading descriptions, so missing secrets are reported at once
+func resolveAuthSecrets(auth *AuthInfo, secrets SecretsProvider) ([]string, []error) {
+	errs := make([]error, 0)
+	values := make([]string, 0)
+	for _, named := range auth.secrets() {
+		value, err := named.secret.resolveWith(secrets)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s auth %s: %w", auth.Type, named.name, err))
+			continue
+		}
+		named.secret.value = value
+		named.secret.resolved = true
+		values = append(values, value)
+	}
+	return values, errs
+}
+
+// GetKeyDestination get where api key is placed,
+// key is sent as a header if destination is not specified
+func (auth *AuthInfo) GetKeyDestination() ParamDestination {
+	if auth.In == "" {
+		return HeaderPlaced
+	}
+	return auth.In
+}
+
+func validateAuth(auth *AuthInfo) []error {
+	errs := make([]error, 0)
+	requireSecret := func(name string, secret *SecretValue) {
+		if secret.IsEmpty() {
+			errs = append(errs, fmt.Errorf("%s auth requires %s env, file or name", auth.Type, name))
+		}
+	}
+	switch auth.Type {
+	case BasicAuth:
+		if auth.Username == "" {
+			errs = append(errs, fmt.Errorf("%s auth requires username", auth.Type))
+		}
+		requireSecret("password", &auth.Password)
+	case BearerAuth:
+		requireSecret("token", &auth.Token)
+	case APIKeyAuth:
+		if auth.Name == "" {
+			errs = append(errs, fmt.Errorf("%s auth requires key name", auth.Type))
+		}
+		destination := auth.GetKeyDestination()
+		if destination != HeaderPlaced && destination != QueryPlaced {
+			errs = append(errs, fmt.Errorf("%s auth can't be placed in %s", auth.Type, destination))
+		}
+		requireSecret("key", &auth.Key)
+	case OAuth2Auth:
+		if auth.TokenURL == "" {
+			errs = append(errs, fmt.Errorf("%s auth requires token_url", auth.Type))
+		}
+		if auth.ClientID == "" {
+			errs = append(errs, fmt.Errorf("%s auth requires client_id", auth.Type))
+		}
+		requireSecret("client_secret", &auth.ClientSecret)
+	default:
+		errs = append(errs, fmt.Errorf("unsupported auth type %s", auth.Type))
+	}
+	return errs
+}
+
+type oauth2Token struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// cachedToken token of one auth config, it's locked while token
+// is requested so concurrent requests wait for the same token
+type cachedToken struct {
+	mutex sync.Mutex
+	token oauth2Token
+}
+
+// tokenStore caches OAuth2 tokens shared by all hands
+type tokenStore struct {
+	mutex  sync.Mutex
+	tokens map[string]*cachedToken
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{
+		tokens: make(map[string]*cachedToken),
+	}
+}
+
+// cached get token cache of auth config with key
+func (store *tokenStore) cached(key string) *cachedToken {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	cached, ok := store.tokens[key]
+	if !ok {
+		cached = &cachedToken{}
+		store.tokens[key] = cached
+	}
+	return cached
+}
+
+func (store *tokenStore) requestToken(ctx context.Context, client *http.Client, auth *AuthInfo) (oauth2Token, error) {
+	clientSecret, err := auth.ClientSecret.Resolve()
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("Failed to get client secret %w", err)
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(auth.Scopes) != 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("Failed to build token request %w", err)
+	}
+	req.Header.Set("Content-Type", string(FormContent))
+	req.SetBasicAuth(auth.ClientID, clientSecret)
+
+	responce, err := client.Do(req)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("Failed to request token %w", err)
+	}
+	defer responce.Body.Close()
+	if responce.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("Token request failed with status %s", responce.Status)
+	}
+	tokenData := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	err = json.NewDecoder(responce.Body).Decode(&tokenData)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("Failed to decode token %w", err)
+	}
+	if tokenData.AccessToken == "" {
+		return oauth2Token{}, fmt.Errorf("Empty access token got from %s", auth.TokenURL)
+	}
+	lifetime := defaultTokenLifetime
+	if tokenData.ExpiresIn > 0 {
+		lifetime = time.Duration(tokenData.ExpiresIn) * time.Second
+	}
+	return oauth2Token{
+		accessToken: tokenData.AccessToken,
+		expiresAt:   time.Now().Add(lifetime),
+	}, nil
+}
+
+// tokenKey key of the token cache of auth config
+func tokenKey(auth *AuthInfo) string {
+	return strings.Join([]string{auth.TokenURL, auth.ClientID, strings.Join(auth.Scopes, " ")}, "\x00")
+}
+
+// getToken get cached token or request a new one if cached token
+// is about to expire, only requests with the same auth config wait
+// for the token request
+func (store *tokenStore) getToken(ctx context.Context, client *http.Client, auth *AuthInfo) (string, error) {
+	cached := store.cached(tokenKey(auth))
+	cached.mutex.Lock()
+	defer cached.mutex.Unlock()
+	if time.Now().Add(tokenRefreshMargin).Before(cached.token.expiresAt) {
+		return cached.token.accessToken, nil
+	}
+	token, err := store.requestToken(ctx, client, auth)
+	if err != nil {
+		return "", err
+	}
+	cached.token = token
+	return token.accessToken, nil
+}
+
+// dropToken remove token rejected by upstream from cache, token
+// which is already replaced by concurrent request is kept
+func (store *tokenStore) dropToken(auth *AuthInfo, accessToken string) {
+	cached := store.cached(tokenKey(auth))
+	cached.mutex.Lock()
+	defer cached.mutex.Unlock()
+	if cached.token.accessToken == accessToken {
+		cached.token = oauth2Token{}
+	}
+}
+
+// authIdentity identity of credentials the request is sent with,
+// secret values are included so changed credentials give other identity
+func authIdentity(auth *AuthInfo) (string, error) {
+	if auth == nil {
+		return "", nil
+	}
+	identity := []string{string(auth.Type), auth.Username, auth.Name, string(auth.GetKeyDestination()), tokenKey(auth)}
+	for _, named := range auth.secrets() {
+		value, err := named.secret.Resolve()
+		if err != nil {
+			return "", err
+		}
+		identity = append(identity, value)
+	}
+	return strings.Join(identity, "\x00"), nil
+}
+
+// applyAuth add credentials to request
+func applyAuth(ctx context.Context, req *http.Request, auth *AuthInfo, client *http.Client, tokens *tokenStore) error {
+	switch auth.Type {
+	case BasicAuth:
+		password, err := auth.Password.Resolve()
+		if err != nil {
+			return fmt.Errorf("Failed to get password %w", err)
+		}
+		req.SetBasicAuth(auth.Username, password)
+	case BearerAuth:
+		token, err := auth.Token.Resolve()
+		if err != nil {
+			return fmt.Errorf("Failed to get token %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case APIKeyAuth:
+		key, err := auth.Key.Resolve()
+		if err != nil {
+			return fmt.Errorf("Failed to get api key %w", err)
+		}
+		if auth.GetKeyDestination() == QueryPlaced {
+			qry := req.URL.Query()
+			qry.Set(auth.Name, key)
+			req.URL.RawQuery = qry.Encode()
+		} else {
+			req.Header.Set(auth.Name, key)
+		}
+	case OAuth2Auth:
+		token, err := tokens.getToken(ctx, client, auth)
+		if err != nil {
+			return fmt.Errorf("Failed to get oauth2 token %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("Unsupported auth type %s", auth.Type)
+	}
+	return nil
+}
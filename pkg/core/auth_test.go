@@ -0,0 +1,409 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestApplyAuth(t *testing.T) {
+	// проверяем подстановку статических учётных данных в запрос
+	os.Setenv("HANDWITCH_TEST_SECRET", "secret")
+	defer os.Unsetenv("HANDWITCH_TEST_SECRET")
+
+	secretFile, err := ioutil.TempFile("", "handwitch_secret")
+	if err != nil {
+		t.Fatalf("Failed to create secret file %s", err.Error())
+	}
+	defer os.Remove(secretFile.Name())
+	_, err = secretFile.WriteString("file_secret\n")
+	if err != nil {
+		t.Fatalf("Failed to write secret file %s", err.Error())
+	}
+	secretFile.Close()
+
+	testCases := []struct {
+		Name   string
+		Auth   AuthInfo
+		URL    string
+		Header string
+		Value  string
+	}{
+		{
+			Name: "basic",
+			Auth: AuthInfo{
+				Type:     BasicAuth,
+				Username: "user",
+				Password: SecretValue{Env: "HANDWITCH_TEST_SECRET"},
+			},
+			URL:    "http://example.com/entity",
+			Header: "Authorization",
+			Value:  "Basic dXNlcjpzZWNyZXQ=",
+		},
+		{
+			Name: "bearer from file",
+			Auth: AuthInfo{
+				Type:  BearerAuth,
+				Token: SecretValue{File: secretFile.Name()},
+			},
+			URL:    "http://example.com/entity",
+			Header: "Authorization",
+			Value:  "Bearer file_secret",
+		},
+		{
+			Name: "api key in header",
+			Auth: AuthInfo{
+				Type: APIKeyAuth,
+				Name: "X-Api-Key",
+				Key:  SecretValue{Env: "HANDWITCH_TEST_SECRET"},
+			},
+			URL:    "http://example.com/entity",
+			Header: "X-Api-Key",
+			Value:  "secret",
+		},
+		{
+			Name: "api key in query",
+			Auth: AuthInfo{
+				Type: APIKeyAuth,
+				Name: "api_key",
+				In:   QueryPlaced,
+				Key:  SecretValue{Env: "HANDWITCH_TEST_SECRET"},
+			},
+			URL: "http://example.com/entity?api_key=secret",
+		},
+	}
+	for _, testCase := range testCases {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/entity", nil)
+		if err != nil {
+			t.Fatalf("Failed to build request %s", err.Error())
+		}
+		err = applyAuth(context.Background(), req, &testCase.Auth, nil, newTokenStore())
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		if req.URL.String() != testCase.URL {
+			t.Errorf("%s: wrong url expected %s got %s", testCase.Name, testCase.URL, req.URL.String())
+		}
+		if testCase.Header != "" && req.Header.Get(testCase.Header) != testCase.Value {
+			t.Errorf("%s: wrong header %s expected %s got %s", testCase.Name, testCase.Header, testCase.Value, req.Header.Get(testCase.Header))
+		}
+	}
+}
+
+func TestOAuth2TokenCache(t *testing.T) {
+	// проверяем что токен переиспользуется до истечения срока жизни
+	os.Setenv("HANDWITCH_TEST_CLIENT_SECRET", "client_secret")
+	defer os.Unsetenv("HANDWITCH_TEST_CLIENT_SECRET")
+
+	issued := 0
+	expiresIn := 3600
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		clientID, clientSecret, ok := req.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "client_secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.FormValue("grant_type") != "client_credentials" || req.FormValue("scope") != "read write" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		issued++
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token%d", issued),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	auth := AuthInfo{
+		Type:         OAuth2Auth,
+		TokenURL:     serv.URL,
+		ClientID:     "client",
+		ClientSecret: SecretValue{Env: "HANDWITCH_TEST_CLIENT_SECRET"},
+		Scopes:       []string{"read", "write"},
+	}
+	tokens := newTokenStore()
+	expected := []string{"Bearer token1", "Bearer token1"}
+	for i, expect := range expected {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/entity", nil)
+		if err != nil {
+			t.Fatalf("Failed to build request %s", err.Error())
+		}
+		err = applyAuth(context.Background(), req, &auth, serv.Client(), tokens)
+		if err != nil {
+			t.Fatalf("Failed to apply auth on request %d: %s", i, err.Error())
+		}
+		if req.Header.Get("Authorization") != expect {
+			t.Errorf("Wrong authorization on request %d expected %s got %s", i, expect, req.Header.Get("Authorization"))
+		}
+	}
+
+	// токен, который вот-вот истечёт, должен быть обновлён заранее
+	expiresIn = 1
+	tokens = newTokenStore()
+	expected = []string{"Bearer token2", "Bearer token3"}
+	for i, expect := range expected {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com/entity", nil)
+		if err != nil {
+			t.Fatalf("Failed to build request %s", err.Error())
+		}
+		err = applyAuth(context.Background(), req, &auth, serv.Client(), tokens)
+		if err != nil {
+			t.Fatalf("Failed to apply auth on request %d: %s", i, err.Error())
+		}
+		if req.Header.Get("Authorization") != expect {
+			t.Errorf("Wrong authorization on request %d expected %s got %s", i, expect, req.Header.Get("Authorization"))
+		}
+	}
+}
+
+func TestRequestDumpMasksCredentials(t *testing.T) {
+	// проверяем, что учётные данные не попадают в отладочный лог запроса
+	os.Setenv("HANDWITCH_TEST_SECRET", "very_secret_value")
+	defer os.Unsetenv("HANDWITCH_TEST_SECRET")
+
+	var received string
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received = string(body)
+		fmt.Fprint(rw, `{"value": "ok"}`)
+	}))
+	defer serv.Close()
+
+	testCases := []struct {
+		Name string
+		Auth AuthInfo
+	}{
+		{Name: "basic", Auth: AuthInfo{Type: BasicAuth, Username: "user", Password: SecretValue{Env: "HANDWITCH_TEST_SECRET"}}},
+		{Name: "bearer", Auth: AuthInfo{Type: BearerAuth, Token: SecretValue{Env: "HANDWITCH_TEST_SECRET"}}},
+		{Name: "api key in header", Auth: AuthInfo{Type: APIKeyAuth, Name: "X-Api-Key", Key: SecretValue{Env: "HANDWITCH_TEST_SECRET"}}},
+		{Name: "api key in query", Auth: AuthInfo{Type: APIKeyAuth, Name: "api_key", In: QueryPlaced, Key: SecretValue{Env: "HANDWITCH_TEST_SECRET"}}},
+	}
+	for _, testCase := range testCases {
+		auth := testCase.Auth
+		record := URLRecord{
+			URLTemplate: serv.URL + "/entity",
+			Method:      http.MethodPost,
+			RequestBody: &RequestBody{ContentType: TextContent, Template: "payload"},
+			Auth:        &auth,
+			Body:        "{{ .responce.value }}",
+			URLName:     "hand",
+		}
+		hand, err := NewHandProcessor(&record, serv.Client())
+		if err != nil {
+			t.Fatalf("%s: failed to build hand %s", testCase.Name, err.Error())
+		}
+		logs := new(bytes.Buffer)
+		logger := log.New()
+		logger.SetOutput(logs)
+		logger.SetLevel(log.DebugLevel)
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(logger))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		// base64 of basic credentials contains the password too
+		basic := base64.StdEncoding.EncodeToString([]byte("user:very_secret_value"))
+		if strings.Contains(logs.String(), "very_secret_value") || strings.Contains(logs.String(), basic) {
+			t.Errorf("%s: credentials leaked into logs %s", testCase.Name, logs.String())
+		}
+		if !strings.Contains(logs.String(), "payload") {
+			t.Errorf("%s: expected request body in logs %s", testCase.Name, logs.String())
+		}
+		if received != "payload" || buf.String() != "ok" {
+			t.Errorf("%s: expected request body to be sent after dump got %q, output %q", testCase.Name, received, buf.String())
+		}
+	}
+}
+
+func TestOAuth2TokenPerConfigLock(t *testing.T) {
+	// медленный сервер токенов не должен блокировать получение других токенов
+	os.Setenv("HANDWITCH_TEST_CLIENT_SECRET", "client_secret")
+	defer os.Unsetenv("HANDWITCH_TEST_CLIENT_SECRET")
+
+	slowStarted := make(chan struct{})
+	releaseSlow := make(chan struct{})
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			close(slowStarted)
+			<-releaseSlow
+		}
+		fmt.Fprintf(rw, `{"access_token": "token%s", "expires_in": 3600}`, strings.Replace(req.URL.Path, "/", "_", -1))
+	}))
+	defer serv.Close()
+	defer close(releaseSlow)
+
+	authFor := func(path string) *AuthInfo {
+		return &AuthInfo{
+			Type:         OAuth2Auth,
+			TokenURL:     serv.URL + path,
+			ClientID:     "client",
+			ClientSecret: SecretValue{Env: "HANDWITCH_TEST_CLIENT_SECRET"},
+		}
+	}
+	tokens := newTokenStore()
+	go tokens.getToken(context.Background(), serv.Client(), authFor("/slow"))
+	<-slowStarted
+
+	result := make(chan string, 1)
+	go func() {
+		token, err := tokens.getToken(context.Background(), serv.Client(), authFor("/fast"))
+		if err != nil {
+			token = err.Error()
+		}
+		result <- token
+	}()
+	select {
+	case token := <-result:
+		if token != "token_fast" {
+			t.Errorf("expected token_fast got %s", token)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("token request is blocked by other token request")
+	}
+}
+
+func TestOAuth2RejectedTokenRefreshed(t *testing.T) {
+	// проверяем, что отозванный до истечения токен сбрасывается и запрос повторяется с новым
+	os.Setenv("HANDWITCH_TEST_CLIENT_SECRET", "client_secret")
+	defer os.Unsetenv("HANDWITCH_TEST_CLIENT_SECRET")
+
+	issued := 0
+	valid := ""
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			issued++
+			valid = fmt.Sprintf("token%d", issued)
+			fmt.Fprintf(rw, `{"access_token": "%s", "expires_in": 3600}`, valid)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer "+valid {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(rw, `{"value": "ok"}`)
+	}))
+	defer serv.Close()
+
+	record := URLRecord{
+		URLTemplate: serv.URL + "/entity",
+		Auth: &AuthInfo{
+			Type:         OAuth2Auth,
+			TokenURL:     serv.URL + "/token",
+			ClientID:     "client",
+			ClientSecret: SecretValue{Env: "HANDWITCH_TEST_CLIENT_SECRET"},
+		},
+		Body:    "{{ .responce.value }}",
+		URLName: "hand",
+	}
+	hand, err := NewHandProcessor(&record, serv.Client())
+	if err != nil {
+		t.Fatalf("failed to build hand %s", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Fatalf("request %d: unexpected error %s", i, err.Error())
+		}
+		if buf.String() != "ok" {
+			t.Errorf("request %d: expected ok got %q", i, buf.String())
+		}
+		// токен отзывается сервером
+		valid = "revoked"
+	}
+	if issued != 2 {
+		t.Errorf("expected 2 issued tokens got %d", issued)
+	}
+}
+
+func TestAuthSecretsValidated(t *testing.T) {
+	// проверяем, что отсутствующие секреты авторизации находятся при загрузке описаний
+	description := `hand:
+  url_template: http://localhost/items
+  auth:
+    type: basic
+    username: user
+    password:
+      file: /nonexistent/handwitch/password
+  body: ok
+  url_name: hand
+  help: ""
+other:
+  url_template: http://localhost/items
+  auth:
+    type: bearer
+    token:
+      env: HANDWITCH_TEST_MISSING_TOKEN
+  body: ok
+  url_name: other
+  help: ""`
+	_, err := GetDescriptionSourceFromYAML(strings.NewReader(description))
+	if err == nil {
+		t.Fatalf("expected error on missing auth secrets")
+	}
+	for _, expected := range []string{"basic auth password", "bearer auth token: environment variable HANDWITCH_TEST_MISSING_TOKEN is not set"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error %s", expected, err.Error())
+		}
+	}
+}
+
+func TestAuthSecretsFromProvider(t *testing.T) {
+	// проверяем, что секреты авторизации с name берутся из того же провайдера, что и ${NAME}
+	var authorization string
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		authorization = req.Header.Get("Authorization")
+		fmt.Fprint(rw, `{"value": "ok"}`)
+	}))
+	defer serv.Close()
+
+	description := `hand:
+  url_template: ${HOST}/items
+  auth:
+    type: bearer
+    token:
+      name: API_TOKEN
+  body: "{{ .responce.value }}"
+  url_name: hand
+  help: ""`
+	_, err := GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), mapSecrets{"HOST": serv.URL})
+	if err == nil || !strings.Contains(err.Error(), "bearer auth token: secret API_TOKEN") {
+		t.Errorf("expected missing auth secret error got %v", err)
+	}
+
+	source, err := GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), mapSecrets{"HOST": serv.URL, "API_TOKEN": "t0ken"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	processor := NewURLProcessor(source, serv.Client())
+	hand, err := processor.GetHand("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if authorization != "Bearer t0ken" || buf.String() != "ok" {
+		t.Errorf("expected token from provider got %q, output %q", authorization, buf.String())
+	}
+}
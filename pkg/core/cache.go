@@ -0,0 +1,132 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// staleCacheLifetime time to keep expired responces with validators
+	// to revalidate them with conditional request
+	staleCacheLifetime = time.Hour
+	// cacheSweepInterval interval of dropping expired memory cache entries
+	cacheSweepInterval = time.Minute
+)
+
+// CacheInfo hand responce caching description
+type CacheInfo struct {
+	TTL Duration `json:"ttl" yaml:"ttl"`
+}
+
+// CachedResponce responce stored in cache
+type CachedResponce struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+}
+
+// isFresh check if responce can be used without revalidation
+func (responce *CachedResponce) isFresh(ttl time.Duration) bool {
+	return time.Since(responce.StoredAt) < ttl
+}
+
+// hasValidators check if responce can be revalidated
+func (responce *CachedResponce) hasValidators() bool {
+	return responce.Header.Get("ETag") != "" || responce.Header.Get("Last-Modified") != ""
+}
+
+// ResponceCache storage of hand responces
+type ResponceCache interface {
+	// Get get stored responce by key
+	Get(key string) (*CachedResponce, bool)
+	// Set store responce by key, responce can be dropped after lifetime
+	Set(key string, responce *CachedResponce, lifetime time.Duration)
+}
+
+type memoryCacheEntry struct {
+	responce  *CachedResponce
+	expiresAt time.Time
+}
+
+// MemoryCache in-memory responce cache
+type MemoryCache struct {
+	mutex     sync.Mutex
+	entries   map[string]memoryCacheEntry
+	lastSweep time.Time
+}
+
+// NewMemoryCache create empty in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries:   make(map[string]memoryCacheEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get get stored responce by key
+func (cache *MemoryCache) Get(key string) (*CachedResponce, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.responce, true
+}
+
+// Set store responce by key
+func (cache *MemoryCache) Set(key string, responce *CachedResponce, lifetime time.Duration) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(cache.lastSweep) > cacheSweepInterval {
+		for entryKey, entry := range cache.entries {
+			if now.After(entry.expiresAt) {
+				delete(cache.entries, entryKey)
+			}
+		}
+		cache.lastSweep = now
+	}
+	cache.entries[key] = memoryCacheEntry{
+		responce:  responce,
+		expiresAt: now.Add(lifetime),
+	}
+}
+
+// cacheKey build cache key from hand name, method, resolved url, params,
+// rendered headers and identity of auth credentials, so requests sent
+// with different headers or credentials don't share responces
+func cacheKey(handName string, method string, resolvedURL string, params map[string]interface{}, header http.Header, authIdentity string) (string, error) {
+	// json encoder sorts map keys, so same params give same key
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(handName), []byte(method), []byte(resolvedURL), encodedParams, encodedHeader, []byte(authIdentity)} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// conditionalHeaders headers to revalidate stored responce
+func conditionalHeaders(responce *CachedResponce) http.Header {
+	header := http.Header{}
+	if etag := responce.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if lastModified := responce.Header.Get("Last-Modified"); lastModified != "" {
+		header.Set("If-Modified-Since", lastModified)
+	}
+	return header
+}
@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestCachedHand(t *testing.T) {
+	// проверяем кеширование ответов и их перепроверку по ETag
+	var requestsCount int32
+	var revalidatedCount int32
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requestsCount, 1)
+		rw.Header().Set("ETag", `"v1"`)
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidatedCount, 1)
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{
+			"value": req.URL.Query().Get("id"),
+		})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	ttl := 50 * time.Millisecond
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"hand": {
+			URLTemplate: serv.URL + "/entity",
+			Parameters: ParamsDescription{
+				"id": ParamInfo{
+					Name:        "id",
+					Type:        StringType,
+					Destination: QueryPlaced,
+				},
+			},
+			Body:    `{{ .responce.value }} {{ .meta.cached }}`,
+			URLName: "hand",
+			Cache: &CacheInfo{
+				TTL: Duration(ttl),
+			},
+		},
+	}), serv.Client())
+
+	testCases := []struct {
+		Name        string
+		ID          string
+		Wait        time.Duration
+		Output      string
+		Requests    int32
+		Revalidated int32
+	}{
+		{
+			Name:     "first request",
+			ID:       "a",
+			Output:   "a false",
+			Requests: 1,
+		},
+		{
+			Name:     "fresh cache",
+			ID:       "a",
+			Output:   "a true",
+			Requests: 1,
+		},
+		{
+			Name:     "other params",
+			ID:       "b",
+			Output:   "b false",
+			Requests: 2,
+		},
+		{
+			Name:        "revalidated after ttl",
+			ID:          "a",
+			Wait:        2 * ttl,
+			Output:      "a true",
+			Requests:    3,
+			Revalidated: 1,
+		},
+		{
+			Name:        "fresh after revalidation",
+			ID:          "a",
+			Output:      "a true",
+			Requests:    3,
+			Revalidated: 1,
+		},
+	}
+	for _, testCase := range testCases {
+		time.Sleep(testCase.Wait)
+		hand, err := processor.GetHand("hand")
+		if err != nil {
+			t.Fatalf("%s: failed to get hand %s", testCase.Name, err.Error())
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{"id": testCase.ID}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output %s got %s", testCase.Name, testCase.Output, buf.String())
+		}
+		if atomic.LoadInt32(&requestsCount) != testCase.Requests {
+			t.Errorf("%s: expected %d requests got %d", testCase.Name, testCase.Requests, atomic.LoadInt32(&requestsCount))
+		}
+		if atomic.LoadInt32(&revalidatedCount) != testCase.Revalidated {
+			t.Errorf("%s: expected %d revalidations got %d", testCase.Name, testCase.Revalidated, atomic.LoadInt32(&revalidatedCount))
+		}
+	}
+}
+
+func TestCacheValidation(t *testing.T) {
+	// проверяем, что кешировать можно только запросы без изменений
+	testCases := []struct {
+		Name   string
+		Method string
+		TTL    time.Duration
+		Errors int
+	}{
+		{Name: "default method", TTL: time.Minute},
+		{Name: "head", Method: http.MethodHead, TTL: time.Minute},
+		{Name: "lower case get", Method: "get", TTL: time.Minute},
+		{Name: "post", Method: http.MethodPost, TTL: time.Minute, Errors: 1},
+		{Name: "delete", Method: http.MethodDelete, TTL: time.Minute, Errors: 1},
+		{Name: "zero ttl", TTL: 0, Errors: 1},
+	}
+	for _, testCase := range testCases {
+		record := URLRecord{
+			URLTemplate: "http://localhost/entity",
+			Method:      testCase.Method,
+			Body:        "ok",
+			URLName:     "hand",
+			Cache:       &CacheInfo{TTL: Duration(testCase.TTL)},
+		}
+		errs := validateHand(&record)
+		if len(errs) != testCase.Errors {
+			t.Errorf("%s: expected %d errors got %v", testCase.Name, testCase.Errors, errs)
+		}
+	}
+}
+
+func TestCacheKeptWithDescriptions(t *testing.T) {
+	// проверяем, что кеш ответов переживает перезагрузку описаний,
+	// если заголовки и авторизация ручки не изменились
+	var requestsCount int32
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requestsCount, 1)
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{"value": "v"})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	os.Setenv("HANDWITCH_TEST_TOKEN", "token")
+	defer os.Unsetenv("HANDWITCH_TEST_TOKEN")
+	os.Setenv("HANDWITCH_TEST_OTHER_TOKEN", "other")
+	defer os.Unsetenv("HANDWITCH_TEST_OTHER_TOKEN")
+
+	describe := func(body string, version string, tokenEnv string) DescriptionsSource {
+		return NewDescriptionSourceFromDict(URLContrainer{
+			"hand": {
+				URLTemplate: serv.URL + "/entity",
+				Headers:     map[string]string{"X-Version": version},
+				Auth:        &AuthInfo{Type: BearerAuth, Token: SecretValue{Env: tokenEnv}},
+				Body:        body,
+				URLName:     "hand",
+				Cache: &CacheInfo{
+					TTL: Duration(time.Minute),
+				},
+			},
+		})
+	}
+	body := `{{ .responce.value }} {{ .meta.cached }}`
+	processor := NewURLProcessor(describe(`old `+body, "1", "HANDWITCH_TEST_TOKEN"), serv.Client())
+	reloaded := processor.WithDescriptions(describe(`new `+body, "1", "HANDWITCH_TEST_TOKEN"))
+	otherHeader := processor.WithDescriptions(describe(`new `+body, "2", "HANDWITCH_TEST_TOKEN"))
+	otherAuth := processor.WithDescriptions(describe(`new `+body, "1", "HANDWITCH_TEST_OTHER_TOKEN"))
+
+	expected := []string{"old v false", "new v true", "new v false", "new v false"}
+	for i, app := range []URLProcessor{processor, reloaded, otherHeader, otherAuth} {
+		hand, err := app.GetHand("hand")
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Fatalf("unexpected error %s", err.Error())
+		}
+		if buf.String() != expected[i] {
+			t.Errorf("expected output %s got %s", expected[i], buf.String())
+		}
+	}
+	if atomic.LoadInt32(&requestsCount) != 3 {
+		t.Errorf("expected 3 requests got %d", atomic.LoadInt32(&requestsCount))
+	}
+}
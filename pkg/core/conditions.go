@@ -0,0 +1,505 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// condition parsed param condition like `force == true && env in (prod, stage)`
+type condition interface {
+	eval(values map[string]interface{}) bool
+	names() []string
+}
+
+type orCondition struct {
+	left, right condition
+}
+
+func (cond *orCondition) eval(values map[string]interface{}) bool {
+	return cond.left.eval(values) || cond.right.eval(values)
+}
+
+func (cond *orCondition) names() []string {
+	return append(cond.left.names(), cond.right.names()...)
+}
+
+type andCondition struct {
+	left, right condition
+}
+
+func (cond *andCondition) eval(values map[string]interface{}) bool {
+	return cond.left.eval(values) && cond.right.eval(values)
+}
+
+func (cond *andCondition) names() []string {
+	return append(cond.left.names(), cond.right.names()...)
+}
+
+type notCondition struct {
+	cond condition
+}
+
+func (cond *notCondition) eval(values map[string]interface{}) bool {
+	return !cond.cond.eval(values)
+}
+
+func (cond *notCondition) names() []string {
+	return cond.cond.names()
+}
+
+// compareCondition compare param value with literals, values are
+// compared as strings, order operators compare them as numbers
+type compareCondition struct {
+	name     string
+	operator string
+	literals []string
+}
+
+// paramString get string representation of param value, missing is empty
+func paramString(values map[string]interface{}, name string) (string, bool) {
+	value, ok := values[name]
+	if !ok || value == nil {
+		return "", false
+	}
+	return toString(value), true
+}
+
+func (cond *compareCondition) eval(values map[string]interface{}) bool {
+	value, ok := paramString(values, cond.name)
+	switch cond.operator {
+	case "":
+		// param is set and it's value is not false or zero
+		return ok && value != "" && value != "false" && value != "0"
+	case "==":
+		return value == cond.literals[0]
+	case "!=":
+		return value != cond.literals[0]
+	case "in":
+		for _, literal := range cond.literals {
+			if value == literal {
+				return true
+			}
+		}
+		return false
+	}
+	if !ok {
+		return false
+	}
+	number, err := toFloat(value)
+	if err != nil {
+		return false
+	}
+	limit, err := toFloat(cond.literals[0])
+	if err != nil {
+		return false
+	}
+	switch cond.operator {
+	case "<":
+		return number < limit
+	case "<=":
+		return number <= limit
+	case ">":
+		return number > limit
+	case ">=":
+		return number >= limit
+	}
+	return false
+}
+
+func (cond *compareCondition) names() []string {
+	return []string{cond.name}
+}
+
+// conditionParser recursive descent parser of conditions
+type conditionParser struct {
+	tokens []string
+	pos    int
+}
+
+func tokenizeCondition(expression string) ([]string, error) {
+	tokens := make([]string, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unclosed quote at %d", i)
+			}
+			// quotes are kept to tell literals from operators
+			tokens = append(tokens, string(runes[i:end+1]))
+			i = end + 1
+		case strings.ContainsRune("()!,<>=&|", r):
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				switch pair {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, pair)
+					i += 2
+					continue
+				}
+			}
+			if r == '=' || r == '&' || r == '|' {
+				return nil, fmt.Errorf("unexpected %c at %d", r, i)
+			}
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()!,<>=&|\"'", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// parseCondition parse condition expression
+func parseCondition(expression string) (condition, error) {
+	tokens, err := tokenizeCondition(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expression, err)
+	}
+	parser := conditionParser{tokens: tokens}
+	cond, err := parser.parseOr()
+	if err == nil && parser.pos < len(parser.tokens) {
+		err = fmt.Errorf("unexpected %s", parser.tokens[parser.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expression, err)
+	}
+	return cond, nil
+}
+
+// evalCondition check condition against param values, condition parsed
+// on loading descriptions is used if there is one, otherwise expression
+// is parsed on each call, conditions are validated on loading so broken
+// condition is false
+func evalCondition(parsed condition, expression string, values map[string]interface{}) bool {
+	if parsed == nil {
+		var err error
+		parsed, err = parseCondition(expression)
+		if err != nil {
+			return false
+		}
+	}
+	return parsed.eval(values)
+}
+
+func (parser *conditionParser) peek() string {
+	if parser.pos < len(parser.tokens) {
+		return parser.tokens[parser.pos]
+	}
+	return ""
+}
+
+func (parser *conditionParser) next() (string, error) {
+	if parser.pos >= len(parser.tokens) {
+		return "", fmt.Errorf("unexpected end")
+	}
+	token := parser.tokens[parser.pos]
+	parser.pos++
+	return token, nil
+}
+
+func (parser *conditionParser) parseOr() (condition, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek() == "||" {
+		parser.pos++
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *conditionParser) parseAnd() (condition, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek() == "&&" {
+		parser.pos++
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *conditionParser) parseUnary() (condition, error) {
+	token, err := parser.next()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case "!":
+		cond, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notCondition{cond: cond}, nil
+	case "(":
+		cond, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, err := parser.next()
+		if err != nil || closing != ")" {
+			return nil, fmt.Errorf("expected )")
+		}
+		return cond, nil
+	}
+	if !isConditionWord(token) {
+		return nil, fmt.Errorf("expected param name got %s", token)
+	}
+	cond := &compareCondition{name: token}
+	switch operator := parser.peek(); operator {
+	case "==", "!=", "<", "<=", ">", ">=":
+		parser.pos++
+		literal, err := parser.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		cond.operator = operator
+		cond.literals = []string{literal}
+	case "in":
+		parser.pos++
+		opening, err := parser.next()
+		if err != nil || opening != "(" {
+			return nil, fmt.Errorf("expected ( after in")
+		}
+		cond.operator = operator
+		for {
+			literal, err := parser.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			cond.literals = append(cond.literals, literal)
+			separator, err := parser.next()
+			if err != nil {
+				return nil, err
+			}
+			if separator == ")" {
+				break
+			}
+			if separator != "," {
+				return nil, fmt.Errorf("expected , or ) got %s", separator)
+			}
+		}
+	}
+	return cond, nil
+}
+
+func (parser *conditionParser) parseLiteral() (string, error) {
+	token, err := parser.next()
+	if err != nil {
+		return "", err
+	}
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '\'') {
+		return token[1 : len(token)-1], nil
+	}
+	if !isConditionWord(token) {
+		return "", fmt.Errorf("expected value got %s", token)
+	}
+	return token, nil
+}
+
+func isConditionWord(token string) bool {
+	return token != "" && !strings.ContainsAny(token[:1], "()!,<>=&|\"'")
+}
+
+// visibleFor check visible_if condition of param with current values
+func (info *ParamInfo) visibleFor(values map[string]interface{}) bool {
+	return info.VisibleIf == "" || evalCondition(info.visibleIf, info.VisibleIf, values)
+}
+
+// isSet check if param has value
+func isSet(values map[string]interface{}, name string) bool {
+	value, ok := values[name]
+	return ok && value != nil
+}
+
+//IsVisible check if param is offered to user with current values,
+// it's visible_if condition should be met and all params it
+// depends on should be set, computed params are never offered
+func (p *ParamProcessorImp) IsVisible(values map[string]interface{}) bool {
+	if p.Computed != "" {
+		return false
+	}
+	if !p.visibleFor(values) {
+		return false
+	}
+	for _, name := range p.DependsOn {
+		if !isSet(values, name) {
+			return false
+		}
+	}
+	return true
+}
+
+//IsRequiredFor check if param is required with current values,
+// hidden params and params with unset dependencies are never required
+func (p *ParamProcessorImp) IsRequiredFor(values map[string]interface{}) bool {
+	if p.DefaultValue != nil || p.Computed != "" {
+		return false
+	}
+	if !p.visibleFor(values) {
+		return false
+	}
+	for _, name := range p.DependsOn {
+		if !isSet(values, name) {
+			return false
+		}
+	}
+	if p.RequiredIf != "" {
+		return evalCondition(p.requiredIf, p.RequiredIf, values)
+	}
+	// URL placed params can't be skipped
+	return p.Destination == URLPlaced || !p.Optional
+}
+
+// conditionsHelp describe param conditions
+func (p *ParamProcessorImp) conditionsHelp() []string {
+	lines := make([]string, 0)
+	if p.VisibleIf != "" {
+		lines = append(lines, "Visible if: "+p.VisibleIf)
+	}
+	if len(p.DependsOn) != 0 {
+		lines = append(lines, "Depends on: "+strings.Join(p.DependsOn, ", "))
+	}
+	return lines
+}
+
+//GetVisibleParams get parameters offered to user with current values
+func (processor *HandProcessorImp) GetVisibleParams(values map[string]interface{}) (map[string]ParamProcessor, error) {
+	result := make(map[string]ParamProcessor)
+	for paramName := range processor.Parameters {
+		param, err := processor.GetParam(paramName)
+		if err != nil {
+			return result, err
+		}
+		if param.IsVisible(values) {
+			result[paramName] = param
+		}
+	}
+	return result, nil
+}
+
+// hiddenParams get names of params which visible_if condition isn't met,
+// their values aren't sent
+func (processor *HandProcessorImp) hiddenParams(values map[string]interface{}) []string {
+	result := make([]string, 0)
+	for paramName, param := range processor.Parameters {
+		if !param.visibleFor(values) {
+			result = append(result, paramName)
+		}
+	}
+	return result
+}
+
+// validateConditions check param conditions syntax and that they
+// refer to other params of the hand, parsed conditions are kept in paramInfo
+func validateConditions(paramName string, paramInfo *ParamInfo, params ParamsDescription) []error {
+	errs := make([]error, 0)
+	if paramInfo.Destination == URLPlaced && paramInfo.DefaultValue == nil && paramInfo.RequiredIf != "" {
+		errs = append(errs, errors.New("UrlPlaced param can't be conditional"))
+	}
+	// value of hidden param is dropped, url can't be built without it
+	if paramInfo.Destination == URLPlaced && paramInfo.VisibleIf != "" {
+		errs = append(errs, errors.New("UrlPlaced param can't have visible_if"))
+	}
+	checkName := func(field string, name string) {
+		if name == paramName {
+			errs = append(errs, fmt.Errorf("%s refers to the param itself", field))
+		} else if _, ok := params[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s refers to unknown param %s", field, name))
+		}
+	}
+	conditions := []struct {
+		field      string
+		expression string
+		parsed     *condition
+	}{
+		{"visible_if", paramInfo.VisibleIf, &paramInfo.visibleIf},
+		{"required_if", paramInfo.RequiredIf, &paramInfo.requiredIf},
+	}
+	for _, cond := range conditions {
+		if cond.expression == "" {
+			continue
+		}
+		parsed, err := parseCondition(cond.expression)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %w", cond.field, err))
+			continue
+		}
+		*cond.parsed = parsed
+		for _, name := range parsed.names() {
+			checkName(cond.field, name)
+		}
+	}
+	for _, name := range paramInfo.DependsOn {
+		checkName("depends_on", name)
+	}
+	return errs
+}
+
+// validateDependencies check params don't depend on each other in cycle,
+// such params would never be offered to user
+func validateDependencies(params ParamsDescription) []error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(params))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		// params of reported cycle are marked as visited too
+		defer func() { state[name] = visited }()
+		for _, dependency := range params[name].DependsOn {
+			if _, ok := params[dependency]; ok && !visit(dependency) {
+				return false
+			}
+		}
+		return true
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]error, 0)
+	for _, name := range names {
+		if state[name] == unvisited && !visit(name) {
+			errs = append(errs, fmt.Errorf("depends_on of param %s forms a cycle", name))
+		}
+	}
+	return errs
+}
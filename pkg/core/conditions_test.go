@@ -0,0 +1,236 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestConditions(t *testing.T) {
+	// проверяем вычисление условий по значениям параметров
+	values := map[string]interface{}{
+		"force":  true,
+		"env":    "prod",
+		"count":  5,
+		"dry":    false,
+		"reason": "",
+	}
+	testCases := []struct {
+		Condition string
+		Result    bool
+	}{
+		{Condition: "force", Result: true},
+		{Condition: "dry", Result: false},
+		{Condition: "reason", Result: false},
+		{Condition: "missing", Result: false},
+		{Condition: "!missing", Result: true},
+		{Condition: "force == true", Result: true},
+		{Condition: "force != true", Result: false},
+		{Condition: "env == 'prod'", Result: true},
+		{Condition: `env == "stage"`, Result: false},
+		{Condition: "missing == ''", Result: true},
+		{Condition: "env in (stage, prod)", Result: true},
+		{Condition: "env in (dev)", Result: false},
+		{Condition: "count > 3 && count <= 5", Result: true},
+		{Condition: "count < 3 || env == prod", Result: true},
+		{Condition: "missing >= 0", Result: false},
+		{Condition: "!(force && env == prod)", Result: false},
+		{Condition: "dry || force && env == dev", Result: false},
+	}
+	for _, testCase := range testCases {
+		cond, err := parseCondition(testCase.Condition)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Condition, err.Error())
+			continue
+		}
+		if result := cond.eval(values); result != testCase.Result {
+			t.Errorf("%s: expected %v got %v", testCase.Condition, testCase.Result, result)
+		}
+	}
+}
+
+func TestConditionsSyntax(t *testing.T) {
+	// проверяем ошибки разбора условий
+	testCases := []string{
+		"",
+		"force ==",
+		"force = true",
+		"env in prod",
+		"env in (prod",
+		"(force",
+		"force true",
+		"env == 'prod",
+		"&& force",
+	}
+	for _, testCase := range testCases {
+		if _, err := parseCondition(testCase); err == nil {
+			t.Errorf("%q: expected error", testCase)
+		}
+	}
+}
+
+func TestConditionalParams(t *testing.T) {
+	// проверяем обязательность и видимость параметров в зависимости от значений
+	record := &URLRecord{
+		URLName: "deploy",
+		Parameters: ParamsDescription{
+			"force":  {Name: "force", Type: BoolType, Destination: QueryPlaced, DefaultValue: false},
+			"reason": {Name: "reason", Type: StringType, Destination: QueryPlaced, RequiredIf: "force == true"},
+			"tenant": {Name: "tenant", Type: StringType, Destination: QueryPlaced},
+			"deployment": {
+				Name: "deployment", Type: StringType, Destination: QueryPlaced,
+				DependsOn: []string{"tenant"},
+			},
+			"region": {Name: "region", Type: StringType, Destination: QueryPlaced, VisibleIf: "tenant == eu"},
+		},
+	}
+	processor := newHandProcessor(record, nil, nil, nil)
+	names := func(params []ParamProcessor) []string {
+		result := make([]string, 0, len(params))
+		for _, param := range params {
+			result = append(result, param.GetInfo().Name)
+		}
+		sort.Strings(result)
+		return result
+	}
+	testCases := []struct {
+		Name     string
+		Values   map[string]interface{}
+		Required []string
+		Visible  []string
+	}{
+		{
+			Name:     "nothing entered",
+			Values:   map[string]interface{}{},
+			Required: []string{"tenant"},
+			Visible:  []string{"force", "reason", "tenant"},
+		},
+		{
+			Name:     "forced",
+			Values:   map[string]interface{}{"force": true, "tenant": "us"},
+			Required: []string{"deployment", "reason", "tenant"},
+			Visible:  []string{"deployment", "force", "reason", "tenant"},
+		},
+		{
+			Name:     "eu tenant",
+			Values:   map[string]interface{}{"tenant": "eu"},
+			Required: []string{"deployment", "region", "tenant"},
+			Visible:  []string{"deployment", "force", "reason", "region", "tenant"},
+		},
+	}
+	for _, testCase := range testCases {
+		required, err := processor.GetRequiredParamsFor(testCase.Values)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", testCase.Name, err.Error())
+		}
+		if got := names(required); !reflect.DeepEqual(got, testCase.Required) {
+			t.Errorf("%s: expected required %v got %v", testCase.Name, testCase.Required, got)
+		}
+		visibleParams, err := processor.GetVisibleParams(testCase.Values)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", testCase.Name, err.Error())
+		}
+		visible := make([]ParamProcessor, 0, len(visibleParams))
+		for _, param := range visibleParams {
+			visible = append(visible, param)
+		}
+		if got := names(visible); !reflect.DeepEqual(got, testCase.Visible) {
+			t.Errorf("%s: expected visible %v got %v", testCase.Name, testCase.Visible, got)
+		}
+	}
+
+	// без значений обязательны только безусловно обязательные параметры
+	required, err := processor.GetRequiredParams()
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if got := names(required); !reflect.DeepEqual(got, []string{"deployment", "tenant"}) {
+		t.Errorf("expected required [deployment tenant] got %v", got)
+	}
+
+	// значения скрытых параметров не отправляются
+	params := map[string]interface{}{"tenant": "us", "region": "eu-west"}
+	err = processor.mergeWithDefault(context.Background(), params, log.NewEntry(log.New()))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if _, ok := params["region"]; ok {
+		t.Errorf("value of hidden param region is kept")
+	}
+	if params["force"] != false {
+		t.Errorf("expected default value of force got %v", params["force"])
+	}
+
+	// в помощи условно обязательный параметр не помечается необязательным
+	reason, err := processor.GetParam("reason")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var help strings.Builder
+	err = reason.WriteHelp(&help)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if !strings.Contains(help.String(), "[Required if: force == true]") || strings.Contains(help.String(), "[Optional]") {
+		t.Errorf("expected required condition in help got %s", help.String())
+	}
+}
+
+func TestConditionsValidation(t *testing.T) {
+	// проверяем проверку условий при загрузке описаний
+	params := ParamsDescription{
+		"force":  {Name: "force", Type: BoolType, Destination: QueryPlaced},
+		"reason": {Name: "reason", Type: StringType, Destination: QueryPlaced},
+	}
+	testCases := []struct {
+		Name   string
+		Info   ParamInfo
+		Errors int
+	}{
+		{Name: "valid", Info: ParamInfo{Name: "reason", Destination: QueryPlaced, RequiredIf: "force", DependsOn: []string{"force"}}},
+		{Name: "syntax", Info: ParamInfo{Name: "reason", Destination: QueryPlaced, VisibleIf: "force =="}, Errors: 1},
+		{Name: "unknown param", Info: ParamInfo{Name: "reason", Destination: QueryPlaced, RequiredIf: "mode == x", DependsOn: []string{"tenant"}}, Errors: 2},
+		{Name: "self reference", Info: ParamInfo{Name: "reason", Destination: QueryPlaced, VisibleIf: "reason"}, Errors: 1},
+		{Name: "conditional url param", Info: ParamInfo{Name: "reason", Destination: URLPlaced, RequiredIf: "force"}, Errors: 1},
+		{Name: "hidden url param with default", Info: ParamInfo{Name: "reason", Destination: URLPlaced, DefaultValue: "all", VisibleIf: "force"}, Errors: 1},
+		{Name: "required url param with default", Info: ParamInfo{Name: "reason", Destination: URLPlaced, DefaultValue: "all", RequiredIf: "force"}},
+		{Name: "unnamed self reference", Info: ParamInfo{Destination: QueryPlaced, VisibleIf: "reason"}, Errors: 1},
+	}
+	for _, testCase := range testCases {
+		errs := validateConditions("reason", &testCase.Info, params)
+		if len(errs) != testCase.Errors {
+			t.Errorf("%s: expected %d errors got %v", testCase.Name, testCase.Errors, errs)
+		}
+	}
+
+	// разобранные условия сохраняются и используются при вычислении
+	info := ParamInfo{Name: "reason", Destination: QueryPlaced, VisibleIf: "force", RequiredIf: "force == true"}
+	errs := validateConditions("reason", &info, params)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if info.visibleIf == nil || info.requiredIf == nil {
+		t.Fatalf("expected parsed conditions to be kept")
+	}
+	info.VisibleIf = "broken =="
+	info.RequiredIf = "broken =="
+	processor := NewParamProcessor(info)
+	values := map[string]interface{}{"force": true}
+	if !processor.IsVisible(values) || !processor.IsRequiredFor(values) {
+		t.Errorf("expected parsed conditions to be used")
+	}
+
+	cyclic := ParamsDescription{
+		"a": {Name: "a", DependsOn: []string{"b"}},
+		"b": {Name: "b", DependsOn: []string{"a"}},
+		"c": {Name: "c", DependsOn: []string{"a"}},
+	}
+	errs = validateDependencies(cyclic)
+	if len(errs) != 1 || errs[0].Error() != "depends_on of param a forms a cycle" {
+		t.Errorf("expected cycle error got %v", errs)
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// hasConstraints check if any value constraint is declared
+func (info *ParamInfo) hasConstraints() bool {
+	return info.Min != nil || info.Max != nil || info.MinLength != nil || info.MaxLength != nil ||
+		info.Pattern != "" || len(info.OneOf) != 0
+}
+
+// numericValue get value to compare with min and max,
+// durations are compared in seconds
+func numericValue(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed), true
+	case float64:
+		return typed, true
+	case Duration:
+		return time.Duration(typed).Seconds(), true
+	}
+	return 0, false
+}
+
+// formatLimit format min and max without trailing zeros
+func formatLimit(limit float64) string {
+	return strconv.FormatFloat(limit, 'f', -1, 64)
+}
+
+// checkConstraints check parsed param value, length constraints of lists
+// are applied to number of elements, other constraints to each element
+func checkConstraints(info *ParamInfo, value interface{}) error {
+	if !info.hasConstraints() {
+		return nil
+	}
+	if list, ok := value.(ListValue); ok {
+		err := checkLength(info, len(list), "number of elements")
+		if err != nil {
+			return err
+		}
+		for _, element := range list {
+			err = checkValue(info, element)
+			if err != nil {
+				return fmt.Errorf("invalid list element %w", err)
+			}
+		}
+		return nil
+	}
+	if str, ok := value.(string); ok {
+		err := checkLength(info, utf8.RuneCountInString(str), "length")
+		if err != nil {
+			return err
+		}
+	}
+	return checkValue(info, value)
+}
+
+func checkLength(info *ParamInfo, length int, what string) error {
+	if info.MinLength != nil && length < *info.MinLength {
+		return fmt.Errorf("%s %d is less than minimum %d", what, length, *info.MinLength)
+	}
+	if info.MaxLength != nil && length > *info.MaxLength {
+		return fmt.Errorf("%s %d is greater than maximum %d", what, length, *info.MaxLength)
+	}
+	return nil
+}
+
+func checkValue(info *ParamInfo, value interface{}) error {
+	if number, ok := numericValue(value); ok {
+		if info.Min != nil && number < *info.Min {
+			return fmt.Errorf("value %v is less than minimum %s", value, formatLimit(*info.Min))
+		}
+		if info.Max != nil && number > *info.Max {
+			return fmt.Errorf("value %v is greater than maximum %s", value, formatLimit(*info.Max))
+		}
+	}
+	str := toString(value)
+	if info.Pattern != "" {
+		pattern, err := info.compiledPattern()
+		if err != nil {
+			return err
+		}
+		if !pattern.MatchString(str) {
+			return fmt.Errorf("value %q doesn't match pattern %s", str, info.Pattern)
+		}
+	}
+	if len(info.OneOf) != 0 {
+		for _, allowed := range info.OneOf {
+			if toString(allowed) == str {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %q is not one of %s", str, joinOneOf(info.OneOf))
+	}
+	return nil
+}
+
+// compiledPattern get pattern compiled on loading descriptions,
+// pattern of param which isn't validated is compiled on each call
+func (info *ParamInfo) compiledPattern() (*regexp.Regexp, error) {
+	if info.pattern != nil {
+		return info.pattern, nil
+	}
+	return compilePattern(info.Pattern)
+}
+
+// compilePattern compile pattern to match the whole value
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	// pattern is checked alone to keep anchors out of error message
+	_, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %w", err)
+	}
+	return regexp.MustCompile("^(?:" + pattern + ")$"), nil
+}
+
+func joinOneOf(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, toString(value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// constraintsHelp describe declared constraints
+func (info *ParamInfo) constraintsHelp() string {
+	parts := make([]string, 0)
+	if info.Min != nil {
+		parts = append(parts, "min "+formatLimit(*info.Min))
+	}
+	if info.Max != nil {
+		parts = append(parts, "max "+formatLimit(*info.Max))
+	}
+	if info.MinLength != nil {
+		parts = append(parts, fmt.Sprintf("min length %d", *info.MinLength))
+	}
+	if info.MaxLength != nil {
+		parts = append(parts, fmt.Sprintf("max length %d", *info.MaxLength))
+	}
+	if info.Pattern != "" {
+		parts = append(parts, "pattern "+info.Pattern)
+	}
+	if len(info.OneOf) != 0 {
+		parts = append(parts, "one of "+joinOneOf(info.OneOf))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func validateConstraints(info *ParamInfo) []error {
+	errs := make([]error, 0)
+	if info.Min != nil && info.Max != nil && *info.Min > *info.Max {
+		errs = append(errs, errors.New("min is greater than max"))
+	}
+	if (info.MinLength != nil && *info.MinLength < 0) || (info.MaxLength != nil && *info.MaxLength < 0) {
+		errs = append(errs, errors.New("length constraints can't be negative"))
+	}
+	if info.MinLength != nil && info.MaxLength != nil && *info.MinLength > *info.MaxLength {
+		errs = append(errs, errors.New("min_length is greater than max_length"))
+	}
+	info.pattern = nil
+	if info.Pattern != "" {
+		pattern, err := compilePattern(info.Pattern)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		info.pattern = pattern
+	}
+	return errs
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParamConstraints(t *testing.T) {
+	// проверяем ограничения на значения, введённые пользователем
+	floatPtr := func(value float64) *float64 { return &value }
+	intPtr := func(value int) *int { return &value }
+	testCases := []struct {
+		Name  string
+		Info  ParamInfo
+		Input string
+		Error string
+	}{
+		{Name: "in range", Info: ParamInfo{Type: IntegerType, Min: floatPtr(1), Max: floatPtr(10)}, Input: "10"},
+		{Name: "less than min", Info: ParamInfo{Type: IntegerType, Min: floatPtr(1)}, Input: "0", Error: "value 0 is less than minimum 1"},
+		{Name: "greater than max", Info: ParamInfo{Type: FloatType, Max: floatPtr(0.5)}, Input: "0.75", Error: "value 0.75 is greater than maximum 0.5"},
+		{Name: "duration max", Info: ParamInfo{Type: DurationType, Max: floatPtr(3600)}, Input: "2h", Error: "value 2h0m0s is greater than maximum 3600"},
+		{Name: "short string", Info: ParamInfo{Type: StringType, MinLength: intPtr(3)}, Input: "аб", Error: "length 2 is less than minimum 3"},
+		{Name: "long string", Info: ParamInfo{Type: StringType, MaxLength: intPtr(3)}, Input: "абвг", Error: "length 4 is greater than maximum 3"},
+		{Name: "pattern", Info: ParamInfo{Type: StringType, Pattern: "[a-z]+-\\d+"}, Input: "task-12"},
+		{Name: "pattern matches whole value", Info: ParamInfo{Type: StringType, Pattern: "[a-z]+"}, Input: "abc1", Error: "value \"abc1\" doesn't match pattern [a-z]+"},
+		{Name: "one of", Info: ParamInfo{Type: IntegerType, OneOf: []interface{}{1, 5}}, Input: "5"},
+		{Name: "not one of", Info: ParamInfo{Type: StringType, OneOf: []interface{}{"a", "b"}}, Input: "c", Error: "value \"c\" is not one of a, b"},
+		{Name: "list length", Info: ParamInfo{Type: ListType, MaxLength: intPtr(2)}, Input: "a,b,c", Error: "number of elements 3 is greater than maximum 2"},
+		{Name: "list element", Info: ParamInfo{Type: ListType, ElementType: IntegerType, Min: floatPtr(0)}, Input: "1,-1", Error: "invalid list element value -1 is less than minimum 0"},
+	}
+	for _, testCase := range testCases {
+		processor := NewParamProcessor(testCase.Info)
+		_, err := processor.ParseFromString(testCase.Input)
+		if err == nil {
+			if testCase.Error != "" {
+				t.Errorf("%s: expected error %s", testCase.Name, testCase.Error)
+			}
+			continue
+		}
+		if err.Error() != testCase.Error {
+			t.Errorf("%s: expected error %q got %q", testCase.Name, testCase.Error, err.Error())
+		}
+	}
+}
+
+func TestParamConstraintsHelp(t *testing.T) {
+	min := 1.0
+	maxLength := 3
+	processor := NewParamProcessor(ParamInfo{
+		Name:        "id",
+		Type:        IntegerType,
+		Destination: QueryPlaced,
+		Min:         &min,
+		MaxLength:   &maxLength,
+		OneOf:       []interface{}{1, 2},
+		Help:        "id",
+	})
+	buf := new(bytes.Buffer)
+	err := processor.WriteHelp(buf)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	expected := "id(Integer)\tQuery Param\n\tConstraints: min 1, max length 3, one of 1, 2\n\tid\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q got %q", expected, buf.String())
+	}
+}
+
+func TestPatternCompiledOnLoad(t *testing.T) {
+	// проверяем, что шаблон значения компилируется один раз при загрузке описаний
+	description := `hand:
+  url_template: http://localhost/tasks
+  parameters:
+    task:
+      name: task
+      type: string
+      destination: query
+      pattern: "[a-z]+-\\d+"
+  body: ok
+  url_name: hand
+  help: ""`
+	source, err := GetDescriptionSourceFromYAML(strings.NewReader(description))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	record, err := source.GetByName("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := record.Parameters["task"]
+	if info.pattern == nil {
+		t.Fatalf("pattern is not compiled on load")
+	}
+	compiled := info.pattern
+	for _, value := range []string{"task-1", "task-2"} {
+		if err := checkConstraints(&info, value); err != nil {
+			t.Errorf("%s: unexpected error %s", value, err.Error())
+		}
+	}
+	if err := checkConstraints(&info, "task"); err == nil {
+		t.Errorf("expected pattern mismatch error")
+	}
+	if info.pattern != compiled {
+		t.Errorf("pattern is recompiled on check")
+	}
+}
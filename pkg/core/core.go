@@ -3,10 +3,14 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -15,6 +19,8 @@ import (
 // should be placed in query
 // In URL - URL_PARAM
 // Like a query param - QUERY_PARAM
+// In request body - BODY_PARAM
+// Like a request header - HEADER_PARAM
 type ParamDestination string
 
 const (
@@ -22,6 +28,10 @@ const (
 	URLPlaced ParamDestination = "URL"
 	//QueryPlaced query parameter
 	QueryPlaced ParamDestination = "query"
+	//BodyPlaced parameter is a part of request body
+	BodyPlaced ParamDestination = "body"
+	//HeaderPlaced parameter is sent as a request header
+	HeaderPlaced ParamDestination = "header"
 )
 
 // ToString Get String representation of ParamDestination
@@ -35,6 +45,14 @@ func (dst ParamDestination) ToString() (string, error) {
 		{
 			return "Query Param", nil
 		}
+	case BodyPlaced:
+		{
+			return "Body Param", nil
+		}
+	case HeaderPlaced:
+		{
+			return "Header Param", nil
+		}
 	}
 	return "", fmt.Errorf("Wrong parameter destination %s", dst)
 }
@@ -47,6 +65,20 @@ const (
 	IntegerType ParamType = "integer"
 	//StringType param represented as a string
 	StringType ParamType = "string"
+	//FloatType param represented as a floating point number
+	FloatType ParamType = "float"
+	//BoolType param represented as a boolean
+	BoolType ParamType = "bool"
+	//DateType param represented as a date formatted with layout
+	DateType ParamType = "date"
+	//DateTimeType param represented as a date and time formatted with layout
+	DateTimeType ParamType = "datetime"
+	//DurationType param represented as a duration like 1h30m
+	DurationType ParamType = "duration"
+	//EnumType param represented as one of declared choices
+	EnumType ParamType = "enum"
+	//ListType param represented as a list of element type values
+	ListType ParamType = "list"
 )
 
 //ToString Get human-readable String representation
@@ -60,18 +92,73 @@ func (tp ParamType) ToString() (string, error) {
 		{
 			return "String", nil
 		}
+	case FloatType:
+		{
+			return "Float", nil
+		}
+	case BoolType:
+		{
+			return "Boolean", nil
+		}
+	case DateType:
+		{
+			return "Date", nil
+		}
+	case DateTimeType:
+		{
+			return "DateTime", nil
+		}
+	case DurationType:
+		{
+			return "Duration", nil
+		}
+	case EnumType:
+		{
+			return "Enum", nil
+		}
+	case ListType:
+		{
+			return "List", nil
+		}
 	}
 	return "", fmt.Errorf("Wrong parameter type %s", tp)
 }
 
-//ParamInfo Config parameter description
+//ParamInfo Config parameter description, Layout is used by date params,
+// Choices by enum params, Separator and ElementType by list params,
+// Min, Max, MinLength, MaxLength, Pattern and OneOf constrain values,
+// OptionsFrom describes request to load values offered to user,
+// VisibleIf and RequiredIf are conditions on values of other params,
+// DependsOn lists params which should be set before the param is offered,
+// Computed is a template of value of the param which isn't asked from user,
+// DefaultValue is evaluated the same way if it's a template
 type ParamInfo struct {
-	Help         string           `json:"help" yaml:"help"`
-	Name         string           `json:"name" yaml:"name"`
-	Destination  ParamDestination `json:"destination" yaml:"destination"`
-	Type         ParamType        `json:"type" yaml:"type"`
-	Optional     bool             `json:"optional" yaml:"optional"`
-	DefaultValue interface{}      `json:"default_value" yaml:"default_value"`
+	Help         string           `json:"help" yaml:"help,omitempty"`
+	Name         string           `json:"name" yaml:"name,omitempty"`
+	Destination  ParamDestination `json:"destination" yaml:"destination,omitempty"`
+	Type         ParamType        `json:"type" yaml:"type,omitempty"`
+	Optional     bool             `json:"optional" yaml:"optional,omitempty"`
+	DefaultValue interface{}      `json:"default_value" yaml:"default_value,omitempty"`
+	Layout       string           `json:"layout" yaml:"layout,omitempty"`
+	Choices      []string         `json:"choices" yaml:"choices,omitempty"`
+	Separator    string           `json:"separator" yaml:"separator,omitempty"`
+	ElementType  ParamType        `json:"element_type" yaml:"element_type,omitempty"`
+	Min          *float64         `json:"min" yaml:"min,omitempty"`
+	Max          *float64         `json:"max" yaml:"max,omitempty"`
+	MinLength    *int             `json:"min_length" yaml:"min_length,omitempty"`
+	MaxLength    *int             `json:"max_length" yaml:"max_length,omitempty"`
+	Pattern      string           `json:"pattern" yaml:"pattern,omitempty"`
+	OneOf        []interface{}    `json:"one_of" yaml:"one_of,omitempty"`
+	OptionsFrom  *OptionsSource   `json:"options_from" yaml:"options_from,omitempty"`
+	VisibleIf    string           `json:"visible_if" yaml:"visible_if,omitempty"`
+	RequiredIf   string           `json:"required_if" yaml:"required_if,omitempty"`
+	DependsOn    []string         `json:"depends_on" yaml:"depends_on,omitempty"`
+	Computed     string           `json:"computed" yaml:"computed,omitempty"`
+	// pattern Pattern compiled on loading descriptions
+	pattern *regexp.Regexp
+	// visibleIf, requiredIf conditions parsed on loading descriptions
+	visibleIf  condition
+	requiredIf condition
 }
 
 //ParamsDescription Container for param
@@ -82,13 +169,187 @@ var (
 	ErrNonExistentParam = errors.New("Can't Find param")
 )
 
+// BodyContentType content type of request body
+type BodyContentType string
+
+const (
+	//JSONContent body is a json document
+	JSONContent BodyContentType = "application/json"
+	//FormContent body is an url-encoded form
+	FormContent BodyContentType = "application/x-www-form-urlencoded"
+	//TextContent body is a plain text
+	TextContent BodyContentType = "text/plain"
+)
+
+//RequestBody description of the hand request body
+// if Template is empty body placed params are encoded
+// according to ContentType
+type RequestBody struct {
+	ContentType BodyContentType `json:"content_type" yaml:"content_type,omitempty"`
+	Template    string          `json:"template" yaml:"template,omitempty"`
+}
+
+// GetContentType get content type of the body,
+// application/json is used if content type is not specified
+func (body *RequestBody) GetContentType() BodyContentType {
+	if body.ContentType == "" {
+		return JSONContent
+	}
+	return body.ContentType
+}
+
 //URLRecord Full Hand description in configuration file
 type URLRecord struct {
-	URLTemplate string            `json:"URL_template" yaml:"url_template"`
-	Parameters  ParamsDescription `json:"params" yaml:"parameters"`
-	Body        string            `json:"body" yaml:"body"`
-	URLName     string            `json:"name" yaml:"url_name"`
-	Help        string            `json:"help" yaml:"help"`
+	URLTemplate    string            `json:"URL_template" yaml:"url_template,omitempty"`
+	Method         string            `json:"method" yaml:"method,omitempty"`
+	RequestBody    *RequestBody      `json:"request_body" yaml:"request_body,omitempty"`
+	Headers        map[string]string `json:"headers" yaml:"headers,omitempty"`
+	Auth           *AuthInfo         `json:"auth" yaml:"auth,omitempty"`
+	ResponseFormat ResponseFormat    `json:"response_format" yaml:"response_format,omitempty"`
+	Parameters     ParamsDescription `json:"params" yaml:"parameters,omitempty"`
+	Body           string            `json:"body" yaml:"body,omitempty"`
+	ErrorBody      string            `json:"error_body" yaml:"error_body,omitempty"`
+	StatusBodies   map[int]string    `json:"status_bodies" yaml:"status_bodies,omitempty"`
+	URLName        string            `json:"name" yaml:"url_name,omitempty"`
+	Help           string            `json:"help" yaml:"help,omitempty"`
+	Timeout        Duration          `json:"timeout" yaml:"timeout,omitempty"`
+	Retries        int               `json:"retries" yaml:"retries,omitempty"`
+	RetryBackoff   Duration          `json:"retry_backoff" yaml:"retry_backoff,omitempty"`
+	RetryOn        []int             `json:"retry_on" yaml:"retry_on,omitempty"`
+	RetryUnsafe    bool              `json:"retry_unsafe" yaml:"retry_unsafe,omitempty"`
+	Cache          *CacheInfo        `json:"cache" yaml:"cache,omitempty"`
+	Steps          []StepRecord      `json:"steps" yaml:"steps,omitempty"`
+	FanOut         *FanOutInfo       `json:"fan_out" yaml:"fan_out,omitempty"`
+	Pagination     *PaginationInfo   `json:"pagination" yaml:"pagination,omitempty"`
+	// Source file the hand is described in, it's empty
+	// if descriptions aren't loaded from file
+	Source string `json:"-" yaml:"-"`
+	// secretValues values of secrets interpolated into description
+	secretValues []string
+}
+
+// supportedMethods HTTP methods allowed in hand descriptions
+var supportedMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodHead,
+}
+
+const (
+	// DefaultTimeout timeout of the hand request if it isn't specified
+	DefaultTimeout = 30 * time.Second
+	// DefaultRetryBackoff delay before first retry if it isn't specified
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// defaultRetryOn statuses retried if retry_on isn't specified
+var defaultRetryOn = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// GetTimeout get timeout of one request attempt
+func (rec *URLRecord) GetTimeout() time.Duration {
+	if rec.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(rec.Timeout)
+}
+
+// GetRetryBackoff get delay before retry attempt, delay
+// is doubled on every next attempt
+func (rec *URLRecord) GetRetryBackoff(attempt int) time.Duration {
+	backoff := time.Duration(rec.RetryBackoff)
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	return backoff << uint(attempt)
+}
+
+// CanRetry check if failed request can be repeated, requests of not
+// idempotent methods are retried only if retry_unsafe is set, as server
+// could process request even if it's responce was lost
+func (rec *URLRecord) CanRetry() bool {
+	switch rec.GetMethod() {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return rec.RetryUnsafe
+}
+
+// GetRetryOn get responce statuses to be retried
+func (rec *URLRecord) GetRetryOn() []int {
+	if len(rec.RetryOn) == 0 {
+		return defaultRetryOn
+	}
+	return rec.RetryOn
+}
+
+// GetMethod get HTTP method of the hand request,
+// GET is used if method is not specified
+func (rec *URLRecord) GetMethod() string {
+	if rec.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(rec.Method)
+}
+
+// Duration time.Duration parsed from strings like "1m30s",
+// numbers are treated as a number of seconds
+type Duration time.Duration
+
+func parseDuration(raw interface{}) (Duration, error) {
+	switch value := raw.(type) {
+	case string:
+		duration, err := time.ParseDuration(value)
+		return Duration(duration), err
+	case int:
+		return Duration(time.Duration(value) * time.Second), nil
+	case float64:
+		return Duration(value * float64(time.Second)), nil
+	}
+	return 0, fmt.Errorf("Invalid duration %v", raw)
+}
+
+// UnmarshalYAML parse duration from yaml
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+	*d, err = parseDuration(raw)
+	return err
+}
+
+// UnmarshalJSON parse duration from json
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	*d, err = parseDuration(raw)
+	return err
+}
+
+// MarshalYAML write duration as a string
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON write duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// String get human-readable representation
+func (d Duration) String() string {
+	return time.Duration(d).String()
 }
 
 //URLContrainer Container of all URLs
@@ -98,6 +359,10 @@ type URLContrainer map[string]URLRecord
 type URLProcessor struct {
 	container  DescriptionsSource
 	httpClient *http.Client
+	tokens     *tokenStore
+	cache      ResponceCache
+	parseMode  ParseMode
+	options    *optionsCache
 }
 
 //HandProcessor hand processor
@@ -108,7 +373,9 @@ type HandProcessor interface {
 	GetInfo() *URLRecord
 	GetParam(string) (ParamProcessor, error)
 	GetRequiredParams() ([]ParamProcessor, error)
+	GetRequiredParamsFor(values map[string]interface{}) ([]ParamProcessor, error)
 	GetParams() (map[string]ParamProcessor, error)
+	GetVisibleParams(values map[string]interface{}) (map[string]ParamProcessor, error)
 }
 
 //ParamProcessor param processor handles param descriptions
@@ -117,6 +384,9 @@ type ParamProcessor interface {
 	WriteHelp(writer io.Writer) error
 	GetInfo() ParamInfo
 	IsRequired() bool
+	IsRequiredFor(values map[string]interface{}) bool
+	IsVisible(values map[string]interface{}) bool
+	Options() []ParamOption
 }
 
 //NewURLProcessor creates new url processor, using data source and http client
@@ -124,7 +394,39 @@ func NewURLProcessor(container DescriptionsSource, httpClient *http.Client) URLP
 	return URLProcessor{
 		container:  container,
 		httpClient: httpClient,
+		tokens:     newTokenStore(),
+		cache:      NewMemoryCache(),
+		options:    newOptionsCache(),
+	}
+}
+
+//WithDescriptions creates url processor with other descriptions, OAuth2
+// tokens and responce cache are shared with processor, so they survive reload
+func (processor *URLProcessor) WithDescriptions(container DescriptionsSource) URLProcessor {
+	return URLProcessor{
+		container:  container,
+		httpClient: processor.httpClient,
+		tokens:     processor.tokens,
+		cache:      processor.cache,
+		parseMode:  processor.parseMode,
+		options:    newOptionsCache(),
+	}
+}
+
+//SetParseMode set markup of messages hands output is rendered into,
+// values interpolated into body templates are escaped for it
+func (processor *URLProcessor) SetParseMode(mode ParseMode) error {
+	err := validateParseMode(mode)
+	if err != nil {
+		return err
 	}
+	processor.parseMode = mode
+	return nil
+}
+
+//SetCache replace default in-memory responce cache
+func (processor *URLProcessor) SetCache(cache ResponceCache) {
+	processor.cache = cache
 }
 
 //GetHand build hand processor object by name
@@ -133,7 +435,9 @@ func (processor *URLProcessor) GetHand(name string) (HandProcessor, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewHandProcessor(URLInfo, processor.httpClient)
+	hand := newHandProcessor(URLInfo, processor.httpClient, processor.tokens, processor.cache)
+	hand.parseMode = processor.parseMode
+	return hand, nil
 }
 
 //WriteBriefHelp write brief help for every hand in description source
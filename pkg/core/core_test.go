@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -338,6 +340,32 @@ func TestHelp(t *testing.T) {
 							Body:    "",
 							URLName: "handWithDefaultValue",
 						},
+						"handWithHeaders": { // Есть заголовки запроса
+							URLTemplate: "http://example.com/entity",
+							Headers: map[string]string{
+								"Accept-Language": "ru",
+								"X-Api-Version":   "{{ .version }}",
+							},
+							Parameters: ParamsDescription{
+								"X-Tenant-Id": ParamInfo{
+									Name:        "X-Tenant-Id",
+									Help:        "Help to X-Tenant-Id",
+									Type:        StringType,
+									Destination: HeaderPlaced,
+								},
+							},
+							Help:    "help 4 handWithHeaders",
+							Body:    "",
+							URLName: "handWithHeaders",
+						},
+						"handWithMethod": { // Метод отличный от GET
+							URLTemplate: "http://example.com/entity",
+							Method:      "post",
+							Parameters:  ParamsDescription{},
+							Help:        "help 4 handWithMethod",
+							Body:        "",
+							URLName:     "handWithMethod",
+						},
 					},
 				),
 				nil,
@@ -348,6 +376,7 @@ func TestHelp(t *testing.T) {
 					Output: "Name: hand1\n" +
 						"\thand1help\n" +
 						"URL template: http://example.com/entity/{entity_id}/v/{v}\n" +
+						"Method: GET\n" +
 						"Parameters:\n" +
 						"\nQueryParam1(Integer)\tQuery Param\n\tHelp to QueryParam1\n" +
 						"\nQueryParam2(String)\tQuery Param\n\tHelp to QueryParam2\n" +
@@ -359,6 +388,7 @@ func TestHelp(t *testing.T) {
 					Output: "Name: handNoURLParams\n" +
 						"\thelp 4 handNoURLParams\n" +
 						"URL template: http://example.com/entity\n" +
+						"Method: GET\n" +
 						"Parameters:\n" +
 						"\nQueryParam1(Integer)\tQuery Param\n\tHelp to QueryParam1\n" +
 						"\nQueryParam2(String)\tQuery Param\n\tHelp to QueryParam2\n",
@@ -368,6 +398,7 @@ func TestHelp(t *testing.T) {
 					Output: "Name: handNoQueryParams\n" +
 						"\thelp 4 handNoQueryParams\n" +
 						"URL template: http://example.com/entity/{entity_id}/v/{v}\n" +
+						"Method: GET\n" +
 						"Parameters:\n" +
 						"\nentity_id(Integer)\tURL Param\n\tHelp to entity_id\n" +
 						"\nv(String)\tURL Param\n\tHelp to v\n",
@@ -377,6 +408,7 @@ func TestHelp(t *testing.T) {
 					Output: "Name: handWithOptionalParam\n" +
 						"\thelp 4 handWithOptionalParam\n" +
 						"URL template: http://example.com/entity\n" +
+						"Method: GET\n" +
 						"Parameters:\n" +
 						"\nQueryParam1(Integer)\tQuery Param\t[Optional]\n\tHelp to QueryParam1\n" +
 						"\nQueryParam2(String)\tQuery Param\n\tHelp to QueryParam2\n",
@@ -386,9 +418,30 @@ func TestHelp(t *testing.T) {
 					Output: "Name: handWithDefaultValue\n" +
 						"\thelp 4 handWithDefaultValue\n" +
 						"URL template: http://example.com/entity\n" +
+						"Method: GET\n" +
 						"Parameters:\n" +
 						"\nQueryParam1(Integer)\tQuery Param\t[Optional]\n\tDefault: 1\n\tHelp to QueryParam1\n",
 				},
+				TestOutput{
+					HandName: "handWithHeaders",
+					Output: "Name: handWithHeaders\n" +
+						"\thelp 4 handWithHeaders\n" +
+						"URL template: http://example.com/entity\n" +
+						"Method: GET\n" +
+						"Headers:\n" +
+						"\tAccept-Language: ru\n" +
+						"\tX-Api-Version: {{ .version }}\n" +
+						"Parameters:\n" +
+						"\nX-Tenant-Id(String)\tHeader Param\n\tHelp to X-Tenant-Id\n",
+				},
+				TestOutput{
+					HandName: "handWithMethod",
+					Output: "Name: handWithMethod\n" +
+						"\thelp 4 handWithMethod\n" +
+						"URL template: http://example.com/entity\n" +
+						"Method: POST\n" +
+						"Parameters:\n",
+				},
 			},
 		},
 	}
@@ -430,7 +483,7 @@ func TestRender(t *testing.T) {
 	handler := func(rw http.ResponseWriter, req *http.Request) {
 	}
 
-	serv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
 		requests = append(requests, req)
 		handler(rw, req)
 	}))
@@ -621,6 +674,359 @@ Params parm a is 1`, serv.URL),
 				},
 			},
 		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity/{{.entity_id}}", serv.URL),
+							Method:      "PUT",
+							Parameters: ParamsDescription{
+								"entity_id": ParamInfo{
+									Name:        "entity_id",
+									Help:        "Help to entity_id",
+									Type:        IntegerType,
+									Destination: URLPlaced,
+								},
+							},
+							Body:    `Method is {{ .responce.method }}`,
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					err := json.NewEncoder(rw).Encode(map[string]interface{}{
+						"method": req.Method,
+					})
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"entity_id": 1,
+					},
+					Output: `Method is PUT`,
+					Requests: []*http.Request{
+						mustBuildRequest("PUT", fmt.Sprintf("%s/entity/1", serv.URL)),
+					},
+					Err: nil,
+				},
+			},
+		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity", serv.URL),
+							Method:      "POST",
+							RequestBody: &RequestBody{
+								ContentType: JSONContent,
+							},
+							Parameters: ParamsDescription{
+								"name": ParamInfo{
+									Name:        "name",
+									Help:        "Help to name",
+									Type:        StringType,
+									Destination: BodyPlaced,
+								},
+								"count": ParamInfo{
+									Name:        "count",
+									Help:        "Help to count",
+									Type:        IntegerType,
+									Destination: BodyPlaced,
+								},
+								"QueryParam1": ParamInfo{
+									Name:        "QueryParam1",
+									Help:        "Help to QueryParam1",
+									Type:        IntegerType,
+									Destination: QueryPlaced,
+								},
+							},
+							Body:    `{{ .responce.content_type }} {{ .responce.body }}`,
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					body := new(bytes.Buffer)
+					_, err := body.ReadFrom(req.Body)
+					if err != nil {
+						panic(err.Error())
+					}
+					err = json.NewEncoder(rw).Encode(map[string]interface{}{
+						"content_type": req.Header.Get("Content-Type"),
+						"body":         body.String(),
+					})
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"name":        "a",
+						"count":       2,
+						"QueryParam1": 3,
+					},
+					Output: `application/json {"count":2,"name":"a"}`,
+					Requests: []*http.Request{
+						mustBuildRequest("POST", fmt.Sprintf("%s/entity?QueryParam1=3", serv.URL)),
+					},
+					Err: nil,
+				},
+			},
+		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity", serv.URL),
+							Method:      "PATCH",
+							RequestBody: &RequestBody{
+								ContentType: TextContent,
+								Template:    `rename to {{ .name }}`,
+							},
+							Parameters: ParamsDescription{
+								"name": ParamInfo{
+									Name:        "name",
+									Help:        "Help to name",
+									Type:        StringType,
+									Destination: BodyPlaced,
+								},
+							},
+							Body:    `{{ .responce.content_type }} {{ .responce.body }}`,
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					body := new(bytes.Buffer)
+					_, err := body.ReadFrom(req.Body)
+					if err != nil {
+						panic(err.Error())
+					}
+					err = json.NewEncoder(rw).Encode(map[string]interface{}{
+						"content_type": req.Header.Get("Content-Type"),
+						"body":         body.String(),
+					})
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"name": "b",
+					},
+					Output: `text/plain rename to b`,
+					Requests: []*http.Request{
+						mustBuildRequest("PATCH", fmt.Sprintf("%s/entity", serv.URL)),
+					},
+					Err: nil,
+				},
+			},
+		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity", serv.URL),
+							Method:      "POST",
+							RequestBody: &RequestBody{
+								ContentType: JSONContent,
+								Template:    `{"name": "{{ .name }}", "tags": {{ toJson .tags }}}`,
+							},
+							Parameters: ParamsDescription{
+								"name": ParamInfo{
+									Name:        "name",
+									Help:        "Help to name",
+									Type:        StringType,
+									Destination: BodyPlaced,
+								},
+								"tags": ParamInfo{
+									Name:        "tags",
+									Help:        "Help to tags",
+									Type:        ListType,
+									Destination: BodyPlaced,
+								},
+							},
+							Body:    `{{ .responce.name }} {{ .responce.body }}`,
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					body := new(bytes.Buffer)
+					_, err := body.ReadFrom(req.Body)
+					if err != nil {
+						panic(err.Error())
+					}
+					// значения в шаблоне json тела экранируются и не ломают его
+					decoded := map[string]interface{}{}
+					err = json.Unmarshal(body.Bytes(), &decoded)
+					if err != nil {
+						rw.WriteHeader(http.StatusBadRequest)
+					}
+					err = json.NewEncoder(rw).Encode(map[string]interface{}{
+						"name": decoded["name"],
+						"body": body.String(),
+					})
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"name": `a", "admin": true, "b\\`,
+						"tags": []interface{}{"x"},
+					},
+					Output: `a", "admin": true, "b\\ {"name": "a\", \"admin\": true, \"b\\\\", "tags": ["x"]}`,
+					Requests: []*http.Request{
+						mustBuildRequest("POST", fmt.Sprintf("%s/entity", serv.URL)),
+					},
+					Err: nil,
+				},
+			},
+		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity", serv.URL),
+							Headers: map[string]string{
+								"X-Api-Version": "v{{ .version }}",
+							},
+							Parameters: ParamsDescription{
+								"version": ParamInfo{
+									Name:        "version",
+									Help:        "Help to version",
+									Type:        IntegerType,
+									Destination: URLPlaced,
+								},
+								"X-Tenant-Id": ParamInfo{
+									Name:        "X-Tenant-Id",
+									Help:        "Help to X-Tenant-Id",
+									Type:        StringType,
+									Destination: HeaderPlaced,
+								},
+							},
+							Body:    `{{ .responce.version }} {{ .responce.tenant }}`,
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					err := json.NewEncoder(rw).Encode(map[string]interface{}{
+						"version": req.Header.Get("X-Api-Version"),
+						"tenant":  req.Header.Get("X-Tenant-Id"),
+					})
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"version":     2,
+						"X-Tenant-Id": "tenant1",
+					},
+					Output: `v2 tenant1`,
+					Requests: []*http.Request{
+						mustBuildRequest("GET", fmt.Sprintf("%s/entity", serv.URL)),
+					},
+					Err: nil,
+				},
+			},
+		},
+		TestDescription{
+			TestInput{
+				NewDescriptionSourceFromDict(
+					URLContrainer{
+						"hand1": {
+							URLTemplate: fmt.Sprintf("%s/entity/{{.entity_id}}", serv.URL),
+							Parameters: ParamsDescription{
+								"entity_id": ParamInfo{
+									Name:        "entity_id",
+									Help:        "Help to entity_id",
+									Type:        IntegerType,
+									Destination: URLPlaced,
+								},
+							},
+							Body:      `Found {{ .responce.value }} with status {{ .meta.status }} type {{ index .meta.headers "Content-Type" }}`,
+							ErrorBody: `Failed with status {{ .meta.status }}: {{ .responce.error }}`,
+							StatusBodies: map[int]string{
+								http.StatusNotFound: `Entity {{ .meta.params.entity_id }} not found`,
+							},
+							URLName: "ValuableName",
+						},
+					},
+				),
+				func(rw http.ResponseWriter, req *http.Request) {
+					rw.Header().Set("Content-Type", "application/json")
+					var status int
+					var rsp map[string]interface{}
+					switch req.URL.Path {
+					case "/entity/1":
+						status = http.StatusOK
+						rsp = map[string]interface{}{"value": "ValueForValue"}
+					case "/entity/2":
+						status = http.StatusNotFound
+						rsp = map[string]interface{}{"error": "not found"}
+					default:
+						status = http.StatusInternalServerError
+						rsp = map[string]interface{}{"error": "internal"}
+					}
+					rw.WriteHeader(status)
+					err := json.NewEncoder(rw).Encode(rsp)
+					if err != nil {
+						panic(err.Error())
+					}
+				},
+			},
+			TestCases{
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"entity_id": 1,
+					},
+					Output: `Found ValueForValue with status 200 type application/json`,
+					Err:    nil,
+				},
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"entity_id": 2,
+					},
+					Output: `Entity 2 not found`,
+					Err:    nil,
+				},
+				TestOutput{
+					HandName: "hand1",
+					Inp: map[string]interface{}{
+						"entity_id": 3,
+					},
+					Output: `Failed with status 500: internal`,
+					Err:    nil,
+				},
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		input := testCase.Inp
@@ -728,3 +1134,155 @@ func TestRequireMentCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestRetries(t *testing.T) {
+	// проверяем повторы запросов и таймауты
+	var attempts int32
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		attempt := atomic.AddInt32(&attempts, 1)
+		switch req.URL.Path {
+		case "/flaky":
+			if attempt < 3 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/teapot":
+			rw.WriteHeader(http.StatusTeapot)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{
+			"value": "ValueForValue",
+		})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	testCases := []struct {
+		Name     string
+		Record   URLRecord
+		Attempts int32
+		Output   string
+		HasError bool
+	}{
+		{
+			Name: "retried until success",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/flaky",
+				Body:         `{{ .responce.value }} {{ .meta.status }}`,
+				Retries:      3,
+				RetryBackoff: Duration(time.Millisecond),
+			},
+			Attempts: 3,
+			Output:   "ValueForValue 200",
+		},
+		{
+			Name: "retries exhausted",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/flaky",
+				Body:         `{{ .meta.status }}`,
+				Retries:      1,
+				RetryBackoff: Duration(time.Millisecond),
+			},
+			Attempts: 2,
+			Output:   "503",
+		},
+		{
+			Name: "status not in retry_on",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/teapot",
+				Body:         `{{ .meta.status }}`,
+				Retries:      2,
+				RetryBackoff: Duration(time.Millisecond),
+				RetryOn:      []int{http.StatusServiceUnavailable},
+			},
+			Attempts: 1,
+			Output:   "418",
+		},
+		{
+			Name: "status in retry_on",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/teapot",
+				Body:         `{{ .meta.status }}`,
+				Retries:      2,
+				RetryBackoff: Duration(time.Millisecond),
+				RetryOn:      []int{http.StatusTeapot},
+			},
+			Attempts: 3,
+			Output:   "418",
+		},
+		{
+			Name: "post isn't retried",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/flaky",
+				Method:       http.MethodPost,
+				Body:         `{{ .meta.status }}`,
+				Retries:      3,
+				RetryBackoff: Duration(time.Millisecond),
+			},
+			Attempts: 1,
+			Output:   "503",
+		},
+		{
+			Name: "post timeout isn't retried",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/slow",
+				Method:       http.MethodPost,
+				Body:         `{{ .responce.value }}`,
+				Timeout:      Duration(10 * time.Millisecond),
+				Retries:      1,
+				RetryBackoff: Duration(time.Millisecond),
+			},
+			Attempts: 1,
+			HasError: true,
+		},
+		{
+			Name: "post retried with retry_unsafe",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/flaky",
+				Method:       http.MethodPost,
+				Body:         `{{ .responce.value }} {{ .meta.status }}`,
+				Retries:      3,
+				RetryBackoff: Duration(time.Millisecond),
+				RetryUnsafe:  true,
+			},
+			Attempts: 3,
+			Output:   "ValueForValue 200",
+		},
+		{
+			Name: "timeout",
+			Record: URLRecord{
+				URLTemplate:  serv.URL + "/slow",
+				Body:         `{{ .responce.value }}`,
+				Timeout:      Duration(10 * time.Millisecond),
+				Retries:      1,
+				RetryBackoff: Duration(time.Millisecond),
+			},
+			Attempts: 2,
+			HasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		atomic.StoreInt32(&attempts, 0)
+		record := testCase.Record
+		record.URLName = "hand"
+		hand, err := NewHandProcessor(&record, serv.Client())
+		if err != nil {
+			t.Fatalf("%s: failed to build hand %s", testCase.Name, err.Error())
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+		if (err != nil) != testCase.HasError {
+			t.Errorf("%s: unexpected error state %v", testCase.Name, err)
+		}
+		if atomic.LoadInt32(&attempts) != testCase.Attempts {
+			t.Errorf("%s: expected %d attempts got %d", testCase.Name, testCase.Attempts, atomic.LoadInt32(&attempts))
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output %s got %s", testCase.Name, testCase.Output, buf.String())
+		}
+	}
+}
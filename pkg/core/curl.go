@@ -0,0 +1,356 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNoCurlCommands input has no curl commands
+	ErrNoCurlCommands = errors.New("no curl commands found")
+
+	// curlShortFlags long names of curl short flags
+	curlShortFlags = map[byte]string{
+		'X': "request", 'H': "header", 'd': "data", 'u': "user", 'A': "user-agent",
+		'e': "referer", 'b': "cookie", 'F': "form", 'G': "get", 'I': "head",
+		'm': "max-time", 'o': "output", 'x': "proxy", 'w': "write-out", 'T': "upload-file",
+		'r': "range", 'U': "proxy-user", 'K': "config", 'c': "cookie-jar", 'E': "cert",
+		'C': "continue-at", 'Y': "speed-limit", 'y': "speed-time", 'z': "time-cond",
+	}
+	// curlValueFlags curl flags which have value
+	curlValueFlags = map[string]bool{
+		"request": true, "header": true, "data": true, "data-raw": true, "data-ascii": true,
+		"data-binary": true, "data-urlencode": true, "json": true, "form": true, "form-string": true,
+		"user": true, "user-agent": true, "referer": true, "cookie": true, "url": true,
+		"max-time": true, "connect-timeout": true, "retry": true, "retry-delay": true,
+		"retry-max-time": true, "output": true, "proxy": true, "write-out": true, "upload-file": true,
+		"range": true, "proxy-user": true, "config": true, "cookie-jar": true, "cert": true,
+		"key": true, "cacert": true, "capath": true, "resolve": true, "limit-rate": true,
+		"oauth2-bearer": true, "aws-sigv4": true, "interface": true, "max-redirs": true,
+		"max-filesize": true, "unix-socket": true, "continue-at": true, "speed-limit": true,
+		"speed-time": true, "time-cond": true, "connect-to": true, "dns-servers": true,
+	}
+)
+
+// shellCommands split shell script into words of commands, commands are
+// separated by new lines and operators, quotes and escapes of sh are supported
+func shellCommands(script string) ([][]string, error) {
+	commands := make([][]string, 0)
+	words := make([]string, 0)
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) != 0 {
+			commands = append(commands, words)
+			words = make([]string, 0)
+		}
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(script) && script[i+1] == '\n' {
+				i++
+				continue
+			}
+			if i+2 < len(script) && script[i+1] == '\r' && script[i+2] == '\n' {
+				i += 2
+				continue
+			}
+			if i+1 < len(script) {
+				i++
+				word.WriteByte(script[i])
+			}
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(script[i+1:], '\'')
+			if end == -1 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(script[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '$' && i+1 < len(script) && script[i+1] == '\'':
+			// ansi-c quoting used by browsers in "copy as curl"
+			i += 2
+			for ; i < len(script) && script[i] != '\''; i++ {
+				if script[i] == '\\' && i+1 < len(script) {
+					i++
+					switch script[i] {
+					case 'n':
+						word.WriteByte('\n')
+					case 't':
+						word.WriteByte('\t')
+					case 'r':
+						word.WriteByte('\r')
+					default:
+						word.WriteByte(script[i])
+					}
+					continue
+				}
+				word.WriteByte(script[i])
+			}
+			if i >= len(script) {
+				return nil, errors.New("unterminated single quote")
+			}
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(script) && script[i] != '"'; i++ {
+				if script[i] == '\\' && i+1 < len(script) && strings.IndexByte("$`\"\\\n", script[i+1]) != -1 {
+					i++
+					if script[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(script[i])
+			}
+			if i >= len(script) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case c == '#' && !inWord:
+			for i+1 < len(script) && script[i+1] != '\n' {
+				i++
+			}
+		case c == '\n' || strings.IndexByte("|;&<>", c) != -1:
+			// rest of pipeline isn't a curl command and is ignored
+			endCommand()
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// curlParser collects request from curl command flags
+type curlParser struct {
+	request *importedRequest
+	data    []string
+	get     bool
+	head    bool
+	logger  *log.Entry
+}
+
+// applyFlag apply curl flag with long name
+func (parser *curlParser) applyFlag(name string, value string) error {
+	request := parser.request
+	switch name {
+	case "request":
+		request.Method = value
+	case "header":
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			parser.logger.Warnf("Header %s without value is skipped", value)
+			return nil
+		}
+		request.Headers = append(request.Headers, importedField{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+	case "data", "data-raw", "data-ascii", "data-binary":
+		if strings.HasPrefix(value, "@") && name != "data-raw" {
+			parser.logger.Warnf("Data from file %s is skipped", value[1:])
+			return nil
+		}
+		parser.data = append(parser.data, value)
+	case "data-urlencode":
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) == 2 {
+			parser.data = append(parser.data, parts[0]+"="+url.QueryEscape(parts[1]))
+		} else {
+			parser.data = append(parser.data, url.QueryEscape(value))
+		}
+	case "json":
+		parser.data = append(parser.data, value)
+		request.Headers = append(request.Headers,
+			importedField{Key: "Content-Type", Value: string(JSONContent)},
+			importedField{Key: "Accept", Value: string(JSONContent)})
+	case "form", "form-string", "upload-file":
+		parser.logger.Warnf("Multipart forms and uploads aren't supported, --%s %s is skipped", name, value)
+	case "user":
+		parts := strings.SplitN(value, ":", 2)
+		request.Auth = &importedAuth{Type: BasicAuth, Username: parts[0]}
+		if len(parts) == 2 {
+			request.Auth.Secret = parts[1]
+		}
+	case "oauth2-bearer":
+		request.Auth = &importedAuth{Type: BearerAuth, Secret: value}
+	case "user-agent":
+		request.Headers = append(request.Headers, importedField{Key: "User-Agent", Value: value})
+	case "referer":
+		request.Headers = append(request.Headers, importedField{Key: "Referer", Value: value})
+	case "cookie":
+		if !strings.Contains(value, "=") {
+			parser.logger.Warnf("Cookies from file %s are skipped", value)
+			return nil
+		}
+		request.Headers = append(request.Headers, importedField{Key: "Cookie", Value: value})
+	case "url":
+		return parser.setURL(value)
+	case "get":
+		parser.get = true
+	case "head":
+		parser.head = true
+	case "max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid max time %s", value)
+		}
+		request.Timeout = Duration(seconds * float64(time.Second))
+	case "retry":
+		retries, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid retries number %s", value)
+		}
+		request.Retries = retries
+	}
+	return nil
+}
+
+func (parser *curlParser) setURL(value string) error {
+	if parser.request.URL != "" {
+		parser.logger.Warnf("Only first url is imported, %s is skipped", value)
+		return nil
+	}
+	if !strings.Contains(value, "://") && !strings.HasPrefix(value, "{{") {
+		// curl uses http if scheme isn't specified
+		value = "http://" + value
+	}
+	parser.request.URL = value
+	return nil
+}
+
+// parseCurl build request from words of curl command
+func parseCurl(words []string, logger *log.Entry) (*importedRequest, error) {
+	parser := curlParser{request: &importedRequest{}, logger: logger}
+	positional := false
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case positional || word == "-" || !strings.HasPrefix(word, "-"):
+			if err := parser.setURL(word); err != nil {
+				return nil, err
+			}
+		case word == "--":
+			positional = true
+		case strings.HasPrefix(word, "--"):
+			name := word[2:]
+			value := ""
+			if parts := strings.SplitN(name, "=", 2); len(parts) == 2 {
+				name, value = parts[0], parts[1]
+			} else if curlValueFlags[name] {
+				if i+1 >= len(words) {
+					return nil, fmt.Errorf("flag %s requires value", word)
+				}
+				i++
+				value = words[i]
+			}
+			if err := parser.applyFlag(name, value); err != nil {
+				return nil, err
+			}
+		default:
+			// short flags can be combined like -sSL or -XPOST
+			for j := 1; j < len(word); j++ {
+				name, ok := curlShortFlags[word[j]]
+				if !ok {
+					continue
+				}
+				if !curlValueFlags[name] {
+					if err := parser.applyFlag(name, ""); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value := word[j+1:]
+				if value == "" {
+					if i+1 >= len(words) {
+						return nil, fmt.Errorf("flag -%c requires value", word[j])
+					}
+					i++
+					value = words[i]
+				}
+				if err := parser.applyFlag(name, value); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	request := parser.request
+	if request.URL == "" {
+		return nil, errors.New("curl command without url")
+	}
+	data := strings.Join(parser.data, "&")
+	switch {
+	case parser.get && data != "":
+		separator := "?"
+		if strings.Contains(request.URL, "?") {
+			separator = "&"
+		}
+		request.URL += separator + data
+	case data != "":
+		// curl sends data as a form if content type isn't specified
+		request.Body = &importedBody{ContentType: string(FormContent), Raw: data}
+	}
+	if request.Method == "" {
+		switch {
+		case parser.head:
+			request.Method = http.MethodHead
+		case request.Body != nil:
+			request.Method = http.MethodPost
+		default:
+			request.Method = http.MethodGet
+		}
+	}
+	return request, nil
+}
+
+//ImportCurl build hands from curl commands, each command is a separate
+// hand named by method and url path, {{variables}} become hand params
+func ImportCurl(reader io.Reader, options ImportOptions, logger *log.Entry) (URLContrainer, error) {
+	script, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := shellCommands(string(script))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to split curl commands %w", err)
+	}
+	converter := requestConverter{options: options, variables: map[string]importedField{}, logger: logger}
+	container := make(URLContrainer)
+	for _, words := range commands {
+		if path.Base(words[0]) != "curl" {
+			continue
+		}
+		request, err := parseCurl(words, logger)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse curl command %s: %w", strings.Join(words, " "), err)
+		}
+		container.addUnique(converter.convert(request))
+	}
+	if len(container) == 0 {
+		return nil, ErrNoCurlCommands
+	}
+	return container, nil
+}
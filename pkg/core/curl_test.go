@@ -0,0 +1,152 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func discardLogger() *log.Entry {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	return log.NewEntry(logger)
+}
+
+func TestShellCommands(t *testing.T) {
+	// проверяем разбор команд с кавычками и переносами строк
+	testCases := []struct {
+		Input  string
+		Output [][]string
+	}{
+		{Input: `curl -H 'A: b c' "x\"y" \` + "\n" + `  next`, Output: [][]string{{"curl", "-H", "A: b c", `x"y`, "next"}}},
+		{Input: "curl $'a\\nb\\'c' | jq .\n# comment\ncurl b", Output: [][]string{{"curl", "a\nb'c"}, {"jq", "."}, {"curl", "b"}}},
+		{Input: `curl a\ b'c'"d"`, Output: [][]string{{"curl", "a bcd"}}},
+	}
+	for _, testCase := range testCases {
+		output, err := shellCommands(testCase.Input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Input, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(output, testCase.Output) {
+			t.Errorf("%s: expected %q got %q", testCase.Input, testCase.Output, output)
+		}
+	}
+	if _, err := shellCommands("curl 'unterminated"); err == nil {
+		t.Errorf("expected error on unterminated quote")
+	}
+}
+
+func TestImportCurl(t *testing.T) {
+	// проверяем построение ручек из curl команд
+	script := `
+curl -sS -X POST 'https://api.example.com/v1/users/:id/posts?lang=en&tag=a&tag=b&token={{token}}' \
+  -H 'Authorization: Bearer abc123' \
+  -H "X-Request-Id: {{requestId}}" \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"title": "{{title}}", "draft": true, "count": 3}' | jq .
+
+curl "{{baseUrl}}/search" -G --data-urlencode 'q=hello world' -u admin:secret -m 2.5
+curl example.com/raw -d '{"nested": {"id": {{id}}}, "name": "{{name}}"}' -H 'content-type: application/json'
+curl -XPUT https://api.example.com/notes -d 'text=prefix {{note}}'
+`
+	container, err := ImportCurl(strings.NewReader(script), ImportOptions{}, discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+
+	post := container["post_v1_users_id_posts"]
+	if post.URLTemplate != "https://api.example.com/v1/users/{{ .id }}/posts" || post.Method != "POST" {
+		t.Errorf("unexpected url %s %s", post.Method, post.URLTemplate)
+	}
+	if post.Auth == nil || post.Auth.Type != BearerAuth || post.Auth.Token.Env != "POST_V1_USERS_ID_POSTS_TOKEN" {
+		t.Errorf("expected token to be read from environment got %+v", post.Auth)
+	}
+	if post.RequestBody == nil || post.RequestBody.GetContentType() != JSONContent || len(post.Headers) != 0 {
+		t.Errorf("unexpected body %+v and headers %v", post.RequestBody, post.Headers)
+	}
+	expectedParams := ParamsDescription{
+		"id":           {Name: "id", Destination: URLPlaced, Type: StringType},
+		"lang":         {Name: "lang", Destination: QueryPlaced, Type: StringType, Optional: true, DefaultValue: "en"},
+		"tag":          {Name: "tag", Destination: QueryPlaced, Type: ListType, ElementType: StringType, Optional: true, DefaultValue: []interface{}{"a", "b"}},
+		"token":        {Name: "token", Destination: QueryPlaced, Type: StringType},
+		"X-Request-Id": {Name: "X-Request-Id", Help: "variable requestId", Destination: HeaderPlaced, Type: StringType},
+		"title":        {Name: "title", Destination: BodyPlaced, Type: StringType},
+		"draft":        {Name: "draft", Destination: BodyPlaced, Type: BoolType, Optional: true, DefaultValue: true},
+		"count":        {Name: "count", Destination: BodyPlaced, Type: IntegerType, Optional: true, DefaultValue: 3},
+	}
+	if !reflect.DeepEqual(post.Parameters, expectedParams) {
+		t.Errorf("expected params %+v got %+v", expectedParams, post.Parameters)
+	}
+
+	search := container["get_search"]
+	if search.URLTemplate != "{{ .baseUrl }}/search" || search.Parameters["q"].DefaultValue != "hello world" {
+		t.Errorf("unexpected search hand %+v", search)
+	}
+	if search.Auth == nil || search.Auth.Username != "admin" || search.Auth.Password.Env != "GET_SEARCH_PASSWORD" {
+		t.Errorf("expected basic auth got %+v", search.Auth)
+	}
+	if search.GetTimeout() != 2500*time.Millisecond {
+		t.Errorf("expected timeout got %s", search.GetTimeout())
+	}
+
+	// вложенный json описывается шаблоном тела запроса
+	raw := container["post_raw"]
+	expectedTemplate := `{"nested": {"id": {{ .id }}}, "name": {{ toJson .name }}}`
+	if raw.URLTemplate != "http://example.com/raw" || raw.RequestBody == nil || raw.RequestBody.Template != expectedTemplate {
+		t.Errorf("unexpected raw hand %+v", raw)
+	}
+
+	notes := container["put_notes"]
+	if notes.Parameters["text"].DefaultValue != "prefix {{ .note }}" || notes.Parameters["note"].Destination != URLPlaced {
+		t.Errorf("expected templated default value got %+v", notes.Parameters)
+	}
+
+	// сгенерированные описания загружаются как обычный файл описаний
+	var output strings.Builder
+	err = WriteDescriptionsYAML(&output, container)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	// секреты авторизации проверяются при загрузке описаний
+	_, err = GetDescriptionSourceFromYAML(strings.NewReader(output.String()))
+	if err == nil || !strings.Contains(err.Error(), "GET_SEARCH_PASSWORD") {
+		t.Errorf("expected missing auth secret error got %v", err)
+	}
+	os.Setenv("GET_SEARCH_PASSWORD", "secret")
+	defer os.Unsetenv("GET_SEARCH_PASSWORD")
+	os.Setenv("POST_V1_USERS_ID_POSTS_TOKEN", "secret")
+	defer os.Unsetenv("POST_V1_USERS_ID_POSTS_TOKEN")
+	if _, err = GetDescriptionSourceFromYAML(strings.NewReader(output.String())); err != nil {
+		t.Errorf("failed to load generated descriptions %s:\n%s", err.Error(), output.String())
+	}
+
+	_, err = ImportCurl(strings.NewReader("wget http://example.com"), ImportOptions{}, discardLogger())
+	if err != ErrNoCurlCommands {
+		t.Errorf("expected no commands error got %v", err)
+	}
+}
+
+func TestImportCurlCredentialHeaders(t *testing.T) {
+	// проверяем, что заголовки с учётными данными читаются из переменных окружения
+	script := `curl https://api.example.com/items -H 'Cookie: session=abc' -H 'X-Api-Key: k3y' -H 'Authorization: Token t0ken' -H 'Accept: application/json'`
+	container, err := ImportCurl(strings.NewReader(script), ImportOptions{}, discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	hand := container["get_items"]
+	expected := map[string]string{
+		"Cookie":        "${GET_ITEMS_COOKIE}",
+		"X-Api-Key":     "${GET_ITEMS_X_API_KEY}",
+		"Authorization": "${GET_ITEMS_AUTHORIZATION}",
+		"Accept":        "application/json",
+	}
+	if !reflect.DeepEqual(hand.Headers, expected) {
+		t.Errorf("expected headers %v got %v", expected, hand.Headers)
+	}
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ResponseFormat format of hand responce body
+type ResponseFormat string
+
+const (
+	//JSONFormat responce is a json document of any shape
+	JSONFormat ResponseFormat = "json"
+	//XMLFormat responce is a xml document
+	XMLFormat ResponseFormat = "xml"
+	//CSVFormat responce is a csv table with header row
+	CSVFormat ResponseFormat = "csv"
+	//TextFormat responce is used as a raw string
+	TextFormat ResponseFormat = "text"
+)
+
+// xmlTextKey key of element text in element with attributes or children
+const xmlTextKey = "#text"
+
+// xmlAttrPrefix prefix of element attribute keys
+const xmlAttrPrefix = "-"
+
+func validateResponseFormat(format ResponseFormat) error {
+	switch format {
+	case "", JSONFormat, XMLFormat, CSVFormat, TextFormat:
+		return nil
+	}
+	return fmt.Errorf("unsupported response format %s", format)
+}
+
+// formatFromContentType guess responce format by it's Content-Type,
+// empty format is returned for unknown content types
+func formatFromContentType(contentType string) ResponseFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JSONFormat
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XMLFormat
+	case mediaType == "text/csv":
+		return CSVFormat
+	case mediaType == "text/plain":
+		return TextFormat
+	}
+	return ""
+}
+
+// decodeResponce decode responce body with explicitly set format
+// or with format got from content type, if format is unknown
+// body is decoded as json and used as a raw string on failure
+func decodeResponce(format ResponseFormat, contentType string, body []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	if format == "" {
+		format = formatFromContentType(contentType)
+	}
+	switch format {
+	case JSONFormat:
+		return decodeJSON(body)
+	case XMLFormat:
+		return decodeXML(body)
+	case CSVFormat:
+		return decodeCSV(body)
+	case TextFormat:
+		return string(body), nil
+	}
+	result, err := decodeJSON(body)
+	if err != nil {
+		return string(body), nil
+	}
+	return result, nil
+}
+
+func decodeJSON(body []byte) (interface{}, error) {
+	var result interface{}
+	err := json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode json result %w", err)
+	}
+	return result, nil
+}
+
+// decodeCSV decode csv table into list of rows,
+// each row is a map from header column name to value
+func decodeCSV(body []byte) (interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode csv result %w", err)
+	}
+	result := make([]interface{}, 0)
+	if len(records) == 0 {
+		return result, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]interface{})
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// decodeXML decode content of xml root element,
+// see decodeXMLElement for details
+func decodeXML(body []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("Failed to decode xml result: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode xml result %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			result, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode xml result %w", err)
+			}
+			return result, nil
+		}
+	}
+}
+
+// decodeXMLElement decode element into string if it has only text
+// otherwise into map with children by name, attributes with "-" prefix
+// and text as "#text", repeated children are collected into list
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	node := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		node[xmlAttrPrefix+attr.Name.Local] = attr.Value
+	}
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, element)
+			if err != nil {
+				return nil, err
+			}
+			name := element.Name.Local
+			switch existing := node[name].(type) {
+			case nil:
+				node[name] = child
+			case []interface{}:
+				node[name] = append(existing, child)
+			default:
+				node[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(element)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(node) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				node[xmlTextKey] = content
+			}
+			return node, nil
+		}
+	}
+}
+
+// lookupPath get value from decoded responce by dotted path like
+// "data.items.0.id", "$." prefix and "[0]" indexes are also accepted
+func lookupPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return data, true
+	}
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
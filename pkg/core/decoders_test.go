@@ -0,0 +1,159 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeResponce(t *testing.T) {
+	// проверяем выбор декодера и форму разобранного ответа
+	testCases := []struct {
+		Name        string
+		Format      ResponseFormat
+		ContentType string
+		Body        string
+		Result      interface{}
+		HasError    bool
+	}{
+		{
+			Name:        "json object",
+			ContentType: "application/json",
+			Body:        `{"value": 1}`,
+			Result:      map[string]interface{}{"value": float64(1)},
+		},
+		{
+			Name:        "json array",
+			ContentType: "application/json; charset=utf-8",
+			Body:        `[1, "a"]`,
+			Result:      []interface{}{float64(1), "a"},
+		},
+		{
+			Name:        "json scalar without content type",
+			ContentType: "",
+			Body:        `"scalar"`,
+			Result:      "scalar",
+		},
+		{
+			Name:        "plain text",
+			ContentType: "text/plain",
+			Body:        "some text",
+			Result:      "some text",
+		},
+		{
+			Name:        "plain text looking like json",
+			ContentType: "text/plain; charset=utf-8",
+			Body:        "42",
+			Result:      "42",
+		},
+		{
+			Name:        "json object sent as plain text",
+			ContentType: "text/plain; charset=utf-8",
+			Body:        `{"value": 1}`,
+			Result:      `{"value": 1}`,
+		},
+		{
+			Name:        "explicit text format",
+			Format:      TextFormat,
+			ContentType: "application/json",
+			Body:        `{"value": 1}`,
+			Result:      `{"value": 1}`,
+		},
+		{
+			Name:        "broken json",
+			ContentType: "application/json",
+			Body:        `{"value": `,
+			HasError:    true,
+		},
+		{
+			Name:        "csv",
+			ContentType: "text/csv",
+			Body:        "id,name\n1,a\n2,b\n",
+			Result: []interface{}{
+				map[string]interface{}{"id": "1", "name": "a"},
+				map[string]interface{}{"id": "2", "name": "b"},
+			},
+		},
+		{
+			Name:        "xml",
+			ContentType: "application/xml",
+			Body: `<?xml version="1.0"?>
+<users count="2">
+	<user id="1">a</user>
+	<user id="2">b</user>
+	<total>2</total>
+</users>`,
+			Result: map[string]interface{}{
+				"-count": "2",
+				"user": []interface{}{
+					map[string]interface{}{"-id": "1", "#text": "a"},
+					map[string]interface{}{"-id": "2", "#text": "b"},
+				},
+				"total": "2",
+			},
+		},
+		{
+			Name:   "xml format for unknown content type",
+			Format: XMLFormat,
+			Body:   `<value>a</value>`,
+			Result: "a",
+		},
+		{
+			Name:        "empty body",
+			ContentType: "application/json",
+			Body:        "",
+			Result:      nil,
+		},
+	}
+	for _, testCase := range testCases {
+		result, err := decodeResponce(testCase.Format, testCase.ContentType, []byte(testCase.Body))
+		if (err != nil) != testCase.HasError {
+			t.Errorf("%s: unexpected error state %v", testCase.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, testCase.Result) {
+			t.Errorf("%s: expected %#v got %#v", testCase.Name, testCase.Result, result)
+		}
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	data := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"id": "1"},
+			},
+		},
+	}
+	testCases := []struct {
+		Path   string
+		Result interface{}
+		Found  bool
+	}{
+		{Path: "", Result: data, Found: true},
+		{Path: "data.items.0.id", Result: "1", Found: true},
+		{Path: "$.data.items[0].id", Result: "1", Found: true},
+		{Path: "data.items.1.id", Found: false},
+		{Path: "data.missing", Found: false},
+		{Path: "data.items.id", Found: false},
+	}
+	for _, testCase := range testCases {
+		result, found := lookupPath(data, testCase.Path)
+		if found != testCase.Found {
+			t.Errorf("%s: expected found %v got %v", testCase.Path, testCase.Found, found)
+			continue
+		}
+		if found && !reflect.DeepEqual(result, testCase.Result) {
+			t.Errorf("%s: expected %#v got %#v", testCase.Path, testCase.Result, result)
+		}
+	}
+}
+
+// jsonHandler handler of test server answering with json, content
+// type can be overridden by handler, text is not decoded as json
+func jsonHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		handler(rw, req)
+	}
+}
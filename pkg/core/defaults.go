@@ -0,0 +1,295 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// keepTimeFuncName name of the function appended to the only action of
+// date param value template, it keeps time result of the action
+const keepTimeFuncName = "keepTime"
+
+//User identity of the user requesting the hand, it's available
+// in templates of computed values and bodies as .meta.user
+type User struct {
+	ID        int
+	Login     string
+	FirstName string
+	LastName  string
+}
+
+type contextKey int
+
+const userKey contextKey = iota
+
+//WithUser get context carrying identity of requesting user
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+//UserFromContext get identity of requesting user, ok is false
+// if context has no user
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey).(User)
+	return user, ok
+}
+
+// addUser add requesting user to templates meta data
+func addUser(ctx context.Context, meta map[string]interface{}) {
+	if user, ok := UserFromContext(ctx); ok {
+		meta["user"] = user
+	}
+}
+
+// isTemplateValue check if default value is evaluated on execution
+func isTemplateValue(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.Contains(str, "{{")
+}
+
+// valueTemplate get template of param value evaluated on execution
+func (info *ParamInfo) valueTemplate() string {
+	if info.Computed != "" {
+		return info.Computed
+	}
+	if isTemplateValue(info.DefaultValue) {
+		return info.DefaultValue.(string)
+	}
+	return ""
+}
+
+// renderValue render template of param value, time built by the only
+// action of date param template is used as is instead of parsing
+// it's text, other values are parsed from rendered text
+func renderValue(paramName string, info ParamInfo, data interface{}) (interface{}, error) {
+	var result interface{}
+	keepTime := func(value interface{}) interface{} {
+		switch value.(type) {
+		case time.Time, TimeValue:
+			result = value
+		}
+		return value
+	}
+	tmpl, err := template.New(paramName).Funcs(templateFuncs(data)).
+		Funcs(template.FuncMap{keepTimeFuncName: keepTime}).Parse(info.valueTemplate())
+	if err != nil {
+		return nil, err
+	}
+	if info.Type == DateType || info.Type == DateTimeType {
+		if action := singleAction(tmpl); action != nil {
+			appendEscape(action.Pipe, keepTimeFuncName)
+		}
+	}
+	rendered, err := executeToString(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		parsed, err := toTime(result)
+		return TimeValue{Time: parsed, Layout: info.GetLayout()}, err
+	}
+	rendered = strings.TrimSpace(rendered)
+	if rendered == "" {
+		return nil, nil
+	}
+	return parseValue(info, rendered)
+}
+
+// singleAction get action of template which output is the action
+// value surrounded by spaces, nil if template has other output
+func singleAction(tmpl *template.Template) *parse.ActionNode {
+	if tmpl.Tree == nil {
+		return nil
+	}
+	var result *parse.ActionNode
+	for _, node := range tmpl.Tree.Root.Nodes {
+		switch typed := node.(type) {
+		case *parse.TextNode:
+			if len(bytes.TrimSpace(typed.Text)) != 0 {
+				return nil
+			}
+		case *parse.ActionNode:
+			if result != nil || len(typed.Pipe.Decl) != 0 {
+				return nil
+			}
+			result = typed
+		default:
+			return nil
+		}
+	}
+	return result
+}
+
+// computeValues evaluate computed params and templated default values,
+// templates get values of params and .meta.user, empty result means
+// that param has no value
+func (processor *HandProcessorImp) computeValues(ctx context.Context, params map[string]interface{}, logger *log.Entry) error {
+	names := make([]string, 0)
+	for paramName, param := range processor.Parameters {
+		if param.valueTemplate() == "" {
+			continue
+		}
+		if _, hasValue := params[paramName]; hasValue && param.Computed == "" {
+			continue
+		}
+		names = append(names, paramName)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	names = processor.computeOrder(names)
+
+	meta := map[string]interface{}{}
+	addUser(ctx, meta)
+	data := make(map[string]interface{}, len(params)+1)
+	for name, value := range params {
+		data[name] = value
+	}
+	data["meta"] = meta
+
+	for _, paramName := range names {
+		info := processor.Parameters[paramName]
+		value, err := renderValue(paramName, info, data)
+		if err == nil && value != nil {
+			err = checkConstraints(&info, value)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to compute value of param %s: %w", paramName, err)
+		}
+		if value == nil {
+			delete(params, paramName)
+			delete(data, paramName)
+			continue
+		}
+		params[paramName] = value
+		// next computed params can use the value
+		data[paramName] = value
+		logger.Debugf("using computed value %v for param %s", value, paramName)
+	}
+	return nil
+}
+
+// computeOrder order computed params so params used in templates of
+// other params are computed first, params referring each other in
+// cycle are computed in name order
+func (processor *HandProcessorImp) computeOrder(names []string) []string {
+	computed := make(map[string]bool, len(names))
+	for _, name := range names {
+		computed[name] = true
+	}
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(name string)
+	visit = func(name string) {
+		if state[name] != 0 {
+			return
+		}
+		state[name] = visiting
+		info := processor.Parameters[name]
+		for _, reference := range templateReferences(name, info.valueTemplate()) {
+			if computed[reference] {
+				visit(reference)
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return order
+}
+
+// templateReferences get sorted names of top level fields used in template
+func templateReferences(name string, text string) []string {
+	tmpl, err := template.New(name).Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil || tmpl.Tree == nil {
+		return nil
+	}
+	references := make(map[string]bool)
+	collectReferences(tmpl.Tree.Root, references)
+	result := make([]string, 0, len(references))
+	for reference := range references {
+		result = append(result, reference)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func collectReferences(node parse.Node, references map[string]bool) {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return
+		}
+		for _, child := range typed.Nodes {
+			collectReferences(child, references)
+		}
+	case *parse.ActionNode:
+		collectReferences(typed.Pipe, references)
+	case *parse.PipeNode:
+		if typed == nil {
+			return
+		}
+		for _, command := range typed.Cmds {
+			collectReferences(command, references)
+		}
+	case *parse.CommandNode:
+		for _, arg := range typed.Args {
+			collectReferences(arg, references)
+		}
+	case *parse.ChainNode:
+		collectReferences(typed.Node, references)
+	case *parse.FieldNode:
+		references[typed.Ident[0]] = true
+	case *parse.VariableNode:
+		if len(typed.Ident) > 1 && typed.Ident[0] == "$" {
+			references[typed.Ident[1]] = true
+		}
+	case *parse.IfNode:
+		collectBranchReferences(&typed.BranchNode, references)
+	case *parse.RangeNode:
+		collectBranchReferences(&typed.BranchNode, references)
+	case *parse.WithNode:
+		collectBranchReferences(&typed.BranchNode, references)
+	case *parse.TemplateNode:
+		collectReferences(typed.Pipe, references)
+	}
+}
+
+func collectBranchReferences(branch *parse.BranchNode, references map[string]bool) {
+	collectReferences(branch.Pipe, references)
+	collectReferences(branch.List, references)
+	collectReferences(branch.ElseList, references)
+}
+
+// validateValueTemplate check templates of computed param and default value
+func validateValueTemplate(paramInfo *ParamInfo) []error {
+	errs := make([]error, 0)
+	if paramInfo.Computed != "" && paramInfo.DefaultValue != nil {
+		errs = append(errs, errors.New("computed param can't have default value"))
+	}
+	text := paramInfo.valueTemplate()
+	if text == "" {
+		return errs
+	}
+	_, err := template.New(paramInfo.Name).Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid value template %w", err))
+	}
+	return errs
+}
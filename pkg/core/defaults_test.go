@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestComputedValues(t *testing.T) {
+	// проверяем вычисляемые параметры и шаблоны значений по умолчанию
+	record := &URLRecord{
+		URLName: "audit",
+		Parameters: ParamsDescription{
+			"since": {
+				Name: "since", Type: DateTimeType, Destination: QueryPlaced,
+				DefaultValue: `{{ now | addTime "-1h" }}`,
+			},
+			"limit": {Name: "limit", Type: IntegerType, Destination: QueryPlaced, DefaultValue: 10},
+			"page_size": {
+				Name: "page_size", Type: IntegerType, Destination: QueryPlaced,
+				Computed: "{{ mul 2 .limit }}",
+			},
+			"author": {
+				Name: "author", Type: StringType, Destination: BodyPlaced,
+				Computed: "{{ with .meta.user }}{{ .Login }}{{ end }}",
+			},
+		},
+	}
+	processor := newHandProcessor(record, nil, nil, nil)
+	logger := log.NewEntry(log.New())
+
+	ctx := WithUser(context.Background(), User{ID: 1, Login: "wolf"})
+	params := map[string]interface{}{"author": "somebody"}
+	err := processor.mergeWithDefault(ctx, params, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	since, ok := params["since"].(TimeValue)
+	if !ok {
+		t.Fatalf("expected time value of since got %#v", params["since"])
+	}
+	if delta := time.Since(since.Time); delta < 59*time.Minute || delta > 61*time.Minute {
+		t.Errorf("expected since an hour ago got %s", since)
+	}
+	if params["page_size"] != 20 {
+		t.Errorf("expected page_size 20 got %v", params["page_size"])
+	}
+	if params["author"] != "wolf" {
+		t.Errorf("expected author wolf got %v", params["author"])
+	}
+
+	// введённое значение заменяет шаблон по умолчанию, но не вычисляемое значение
+	params = map[string]interface{}{"since": "kept", "limit": 3}
+	err = processor.mergeWithDefault(context.Background(), params, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if params["since"] != "kept" || params["page_size"] != 6 {
+		t.Errorf("unexpected values %v", params)
+	}
+	// без пользователя вычисляемый параметр остаётся пустым
+	if _, ok := params["author"]; ok {
+		t.Errorf("expected no author without user got %v", params["author"])
+	}
+
+	params = map[string]interface{}{"limit": "many"}
+	err = processor.mergeWithDefault(context.Background(), params, logger)
+	if err == nil {
+		t.Errorf("expected error on computing page_size")
+	}
+}
+
+func TestComputedValuesChain(t *testing.T) {
+	// проверяем вычисление параметров, зависящих от других вычисляемых, и их ограничения
+	maxTotal := 50.0
+	record := &URLRecord{
+		URLName: "report",
+		Parameters: ParamsDescription{
+			"limit": {Name: "limit", Type: IntegerType, Destination: QueryPlaced, DefaultValue: 10},
+			// имя раньше по алфавиту, но вычисляется после size
+			"a_total": {
+				Name: "a_total", Type: IntegerType, Destination: QueryPlaced,
+				Computed: "{{ mul 2 .size }}", Max: &maxTotal,
+			},
+			"size": {
+				Name: "size", Type: IntegerType, Destination: QueryPlaced,
+				Computed: "{{ with $.limit }}{{ add 1 . }}{{ end }}",
+			},
+			"label": {
+				Name: "label", Type: StringType, Destination: QueryPlaced,
+				DefaultValue: "{{ .a_total }}-{{ .size }}", Pattern: "^[0-9]+-[0-9]+$",
+			},
+		},
+	}
+	processor := newHandProcessor(record, nil, nil, nil)
+	logger := log.NewEntry(log.New())
+
+	params := map[string]interface{}{}
+	err := processor.mergeWithDefault(context.Background(), params, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if params["size"] != 11 || params["a_total"] != 22 || params["label"] != "22-11" {
+		t.Errorf("unexpected values %v", params)
+	}
+
+	// вычисленное значение проверяется ограничениями параметра
+	params = map[string]interface{}{"limit": 30}
+	err = processor.mergeWithDefault(context.Background(), params, logger)
+	if err == nil {
+		t.Errorf("expected max constraint error got values %v", params)
+	}
+}
+
+func TestComputedTimeValues(t *testing.T) {
+	// проверяем, что время из шаблона даты не разбирается из текста
+	record := &URLRecord{
+		URLName: "report",
+		Parameters: ParamsDescription{
+			"day": {Name: "day", Type: DateType, Destination: QueryPlaced, Layout: "date", DefaultValue: " {{ now }} "},
+			"formatted": {
+				Name: "formatted", Type: DateType, Destination: QueryPlaced, Layout: "date",
+				Computed: `{{ if true }}{{ now | formatTime "date" }}{{ end }}`,
+			},
+			"printed": {Name: "printed", Type: DateTimeType, Destination: QueryPlaced, Computed: "{{ if true }}{{ now }}{{ end }}"},
+		},
+	}
+	processor := newHandProcessor(record, nil, nil, nil)
+	logger := log.NewEntry(log.New())
+
+	params := map[string]interface{}{}
+	err := processor.computeValues(context.Background(), params, logger)
+	if err == nil || !strings.Contains(err.Error(), "printed") {
+		t.Fatalf("expected error on computing printed got %v", err)
+	}
+
+	delete(record.Parameters, "printed")
+	params = map[string]interface{}{}
+	err = processor.computeValues(context.Background(), params, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	day, ok := params["day"].(TimeValue)
+	if !ok || time.Since(day.Time) > time.Minute || day.Layout != "2006-01-02" {
+		t.Errorf("expected current time with date layout got %#v", params["day"])
+	}
+	if formatted, ok := params["formatted"].(TimeValue); !ok || formatted.String() != time.Now().Format("2006-01-02") {
+		t.Errorf("expected current date got %#v", params["formatted"])
+	}
+}
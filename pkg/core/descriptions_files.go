@@ -0,0 +1,384 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// includeKey top level key of descriptions file listing included files
+	includeKey = "include"
+	// openAPIIncludeKey top level key of descriptions file listing
+	// OpenAPI documents which hands are built from
+	openAPIIncludeKey = "include_openapi"
+)
+
+var (
+	// ErrIncludeWithoutPath descriptions read not from file can't include files
+	ErrIncludeWithoutPath = errors.New("include is supported only for descriptions loaded from path")
+
+	// descriptionsExtensions extensions of files loaded from descriptions directory
+	descriptionsExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+)
+
+// yamlNode yaml node decoded later, when it's known what it is
+type yamlNode struct {
+	unmarshal func(interface{}) error
+}
+
+func (node *yamlNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	node.unmarshal = unmarshal
+	return nil
+}
+
+// descriptionsFile content of single descriptions file
+type descriptionsFile struct {
+	include        []string
+	includeOpenAPI []string
+	hands          URLContrainer
+}
+
+// hasIncludes check if file includes other files or OpenAPI documents
+func (file *descriptionsFile) hasIncludes() bool {
+	return len(file.include) != 0 || len(file.includeOpenAPI) != 0
+}
+
+func decodeYAMLDescriptions(data []byte) (*descriptionsFile, error) {
+	nodes := make(map[string]yamlNode)
+	err := yaml.Unmarshal(data, &nodes)
+	if err != nil {
+		return nil, err
+	}
+	file := &descriptionsFile{hands: make(URLContrainer, len(nodes))}
+	for name, node := range nodes {
+		if name == includeKey {
+			err = node.unmarshal(&file.include)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include %w", err)
+			}
+			continue
+		}
+		if name == openAPIIncludeKey {
+			err = node.unmarshal(&file.includeOpenAPI)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %w", openAPIIncludeKey, err)
+			}
+			continue
+		}
+		var record URLRecord
+		err = node.unmarshal(&record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hand %s: %w", name, err)
+		}
+		file.hands[name] = record
+	}
+	return file, nil
+}
+
+func decodeJSONDescriptions(data []byte) (*descriptionsFile, error) {
+	nodes := make(map[string]json.RawMessage)
+	err := json.Unmarshal(data, &nodes)
+	if err != nil {
+		return nil, err
+	}
+	file := &descriptionsFile{hands: make(URLContrainer, len(nodes))}
+	for name, node := range nodes {
+		if name == includeKey {
+			err = json.Unmarshal(node, &file.include)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include %w", err)
+			}
+			continue
+		}
+		if name == openAPIIncludeKey {
+			err = json.Unmarshal(node, &file.includeOpenAPI)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %w", openAPIIncludeKey, err)
+			}
+			continue
+		}
+		var record URLRecord
+		err = json.Unmarshal(node, &record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hand %s: %w", name, err)
+		}
+		file.hands[name] = record
+	}
+	return file, nil
+}
+
+// descriptionsLoader merge descriptions of several files
+type descriptionsLoader struct {
+	container URLContrainer
+	// loaded absolute paths of loaded files, file is loaded once
+	// even if it's included several times
+	loaded map[string]bool
+	// paths loaded path and included files and directories
+	paths  []string
+	errs   []error
+	logger *log.Entry
+}
+
+func newDescriptionsLoader(logger *log.Entry) *descriptionsLoader {
+	return &descriptionsLoader{
+		container: make(URLContrainer),
+		loaded:    make(map[string]bool),
+		paths:     make([]string, 0),
+		errs:      make([]error, 0),
+		logger:    logger,
+	}
+}
+
+// loadPath load descriptions file or all descriptions files in directory
+func (loader *descriptionsLoader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	loader.paths = append(loader.paths, absPath)
+	if !info.IsDir() {
+		return loader.loadFile(path)
+	}
+	return filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !descriptionsExtensions[strings.ToLower(filepath.Ext(filePath))] {
+			return nil
+		}
+		return loader.loadScannedFile(filePath)
+	})
+}
+
+// hasHands check if file has at least one hand description or
+// includes other files, parse error is returned for broken files
+func hasHands(path string, data []byte) (bool, error) {
+	isHandsKey := func(name string) bool {
+		return name == includeKey || name == openAPIIncludeKey
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		nodes := make(map[string]json.RawMessage)
+		err := json.Unmarshal(data, &nodes)
+		if err != nil {
+			return false, err
+		}
+		for name, node := range nodes {
+			hand := struct {
+				URLName string `json:"name"`
+			}{}
+			if isHandsKey(name) || (json.Unmarshal(node, &hand) == nil && hand.URLName != "") {
+				return true, nil
+			}
+		}
+	default:
+		nodes := make(map[string]yamlNode)
+		err := yaml.Unmarshal(data, &nodes)
+		if err != nil {
+			return false, err
+		}
+		for name, node := range nodes {
+			hand := struct {
+				URLName string `yaml:"url_name"`
+			}{}
+			if isHandsKey(name) || (node.unmarshal(&hand) == nil && hand.URLName != "") {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// loadScannedFile load file found in descriptions directory, files
+// without hands like bot config or whitelist are skipped, OpenAPI
+// documents are skipped too unless they are included explicitly
+func (loader *descriptionsLoader) loadScannedFile(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if loader.loaded[absPath] {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isOpenAPIDocument(data) {
+		loader.logger.Warnf("OpenAPI document %s in descriptions directory is skipped: add it to %s or generate hands with import command", path, openAPIIncludeKey)
+		return nil
+	}
+	ok, err := hasHands(path, data)
+	if err != nil {
+		return fmt.Errorf("Failed to parse %s: %w", path, err)
+	}
+	if !ok {
+		loader.logger.Warnf("File %s in descriptions directory is skipped: it has no hand descriptions", path)
+		return nil
+	}
+	return loader.loadFile(path)
+}
+
+// decodeFile decode descriptions file by it's extension
+func decodeFile(path string, data []byte) (*descriptionsFile, error) {
+	if isOpenAPIDocument(data) {
+		return nil, fmt.Errorf("it's an OpenAPI document, add it to %s or generate hands with import command", openAPIIncludeKey)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return decodeYAMLDescriptions(data)
+	case ".json":
+		return decodeJSONDescriptions(data)
+	}
+	return nil, fmt.Errorf("unknown file extension %s", filepath.Ext(path))
+}
+
+// addHands add hands of loaded file to loaded descriptions
+func (loader *descriptionsLoader) addHands(path string, hands URLContrainer) {
+	names := make([]string, 0, len(hands))
+	for name := range hands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hand := hands[name]
+		if existing, ok := loader.container[name]; ok {
+			loader.errs = append(loader.errs, fmt.Errorf("hand %s is described both in %s and %s", name, existing.Source, path))
+			continue
+		}
+		hand.Source = path
+		loader.container[name] = hand
+	}
+}
+
+// includeMatches files matching include pattern relative to including file
+func includeMatches(path string, include string) ([]string, error) {
+	pattern := include
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(path), pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include %s in %s: %w", include, path, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("include %s in %s matches no files", include, path)
+	}
+	return matches, nil
+}
+
+// loadOpenAPI build hands from OpenAPI document with default import options
+func (loader *descriptionsLoader) loadOpenAPI(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if loader.loaded[absPath] {
+		return nil
+	}
+	loader.loaded[absPath] = true
+	loader.paths = append(loader.paths, absPath)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	options := ImportOptions{Body: DefaultImportedBody}
+	hands, err := ImportOpenAPI(bytes.NewReader(data), options, loader.logger.WithField("file", path))
+	if err != nil {
+		return fmt.Errorf("Failed to import %s: %w", path, err)
+	}
+	loader.addHands(path, hands)
+	return nil
+}
+
+// loadFile load descriptions file and files it includes
+func (loader *descriptionsLoader) loadFile(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if loader.loaded[absPath] {
+		return nil
+	}
+	loader.loaded[absPath] = true
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file, err := decodeFile(path, data)
+	if err != nil {
+		return fmt.Errorf("Failed to parse %s: %w", path, err)
+	}
+	loader.addHands(path, file.hands)
+
+	for _, include := range file.include {
+		matches, err := includeMatches(path, include)
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			err = loader.loadPath(match)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, include := range file.includeOpenAPI {
+		matches, err := includeMatches(path, include)
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			err = loader.loadOpenAPI(match)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadDescriptionsFromPath load descriptions from yaml or json file, or from
+// all such files in directory and it's subdirectories, files can include
+// other files and directories with include list and OpenAPI or Swagger documents
+// with include_openapi list, paths are relative to file
+func LoadDescriptionsFromPath(path string, secrets SecretsProvider, logger *log.Entry) (*SimpleDescriptionsSource, error) {
+	source, _, err := LoadDescriptionsAndPaths(path, secrets, logger)
+	return source, err
+}
+
+// LoadDescriptionsAndPaths load descriptions like LoadDescriptionsFromPath and
+// return absolute paths of path itself and all included files and directories,
+// so they can be watched for changes
+func LoadDescriptionsAndPaths(path string, secrets SecretsProvider, logger *log.Entry) (*SimpleDescriptionsSource, []string, error) {
+	loader := newDescriptionsLoader(logger)
+	err := loader.loadPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = newValidationError("", loader.errs)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = validateContainer(&loader.container, secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewDescriptionSourceFromDict(loader.container), loader.paths, nil
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDescriptions(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func yamlHand(name string) string {
+	return name + `:
+  url_template: http://localhost/` + name + `
+  body: ok
+  url_name: ` + name + `
+  help: ""
+`
+}
+
+const petsOpenAPIDocument = `openapi: 3.0.0
+servers:
+  - url: http://localhost/api
+paths:
+  /pets:
+    get:
+      operationId: list_pets
+`
+
+func TestLoadDescriptionsFromPath(t *testing.T) {
+	// проверяем загрузку описаний из директории и включаемых файлов
+	dir, err := ioutil.TempDir("", "descriptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeDescriptions(t, dir, map[string]string{
+		"main.yaml":          "include:\n  - teams/*.yaml\n  - shared\n" + yamlHand("main"),
+		"teams/billing.yaml": yamlHand("invoices"),
+		"teams/search.yaml":  "include: [../main.yaml]\n" + yamlHand("search"),
+		"teams/notes.txt":    "not a description",
+		// файлы без ручек в директории пропускаются
+		"teams/config.json":    `{"log_level": "Debug", "path": "./", "telegram": {"white_list": "./whitelist.json"}}`,
+		"teams/whitelist.json": `{"users": ["wolf"]}`,
+		"teams/compose.yml":    "version: '3'\nservices:\n  bot:\n    image: handwitch\n",
+		"teams/all.yaml":       "include: [../shared]\n",
+		"shared/nested/status.json": `{"status": {"URL_template": "http://localhost/status",
+			"body": "ok", "name": "status", "help": ""}}`,
+		// OpenAPI документы подключаются только явно через include_openapi
+		"apis/index.yaml":      "include_openapi: [specs/*.yaml]\n",
+		"apis/specs/pets.yaml": petsOpenAPIDocument,
+		"stray/pets.yaml":      petsOpenAPIDocument,
+		"stray/hand.yaml":      yamlHand("stray"),
+	})
+
+	testCases := []struct {
+		Name    string
+		Path    string
+		Sources map[string]string
+	}{
+		{
+			Name: "file with includes",
+			Path: filepath.Join(dir, "main.yaml"),
+			Sources: map[string]string{
+				"main":     filepath.Join(dir, "main.yaml"),
+				"invoices": filepath.Join(dir, "teams", "billing.yaml"),
+				"search":   filepath.Join(dir, "teams", "search.yaml"),
+				"status":   filepath.Join(dir, "shared", "nested", "status.json"),
+			},
+		},
+		{
+			Name: "directory",
+			Path: filepath.Join(dir, "teams"),
+			Sources: map[string]string{
+				"invoices": filepath.Join(dir, "teams", "billing.yaml"),
+				"search":   filepath.Join(dir, "teams", "search.yaml"),
+				"main":     filepath.Join(dir, "main.yaml"),
+				"status":   filepath.Join(dir, "shared", "nested", "status.json"),
+			},
+		},
+		{
+			Name: "OpenAPI document",
+			Path: filepath.Join(dir, "apis"),
+			Sources: map[string]string{
+				"list_pets": filepath.Join(dir, "apis", "specs", "pets.yaml"),
+			},
+		},
+		{
+			Name: "OpenAPI document in directory is skipped",
+			Path: filepath.Join(dir, "stray"),
+			Sources: map[string]string{
+				"stray": filepath.Join(dir, "stray", "hand.yaml"),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		source, err := LoadDescriptionsFromPath(testCase.Path, EnvSecretsProvider{}, discardLogger())
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		records, _ := source.GetAllRecords()
+		if len(records) != len(testCase.Sources) {
+			t.Errorf("%s: expected %d hands got %d", testCase.Name, len(testCase.Sources), len(records))
+		}
+		for _, record := range records {
+			if record.Source != testCase.Sources[record.URLName] {
+				t.Errorf("%s: expected source of %s %s got %s", testCase.Name, record.URLName, testCase.Sources[record.URLName], record.Source)
+			}
+		}
+	}
+}
+
+func TestLoadDescriptionsPaths(t *testing.T) {
+	// проверяем, что возвращаются пути всех включённых файлов, в том числе вне директории
+	dir, err := ioutil.TempDir("", "descriptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeDescriptions(t, dir, map[string]string{
+		"bot/main.yaml":         "include: [../shared/extra.yaml, ../teams]\n" + yamlHand("main"),
+		"shared/extra.yaml":     yamlHand("extra"),
+		"teams/billing.yaml":    yamlHand("invoices"),
+		"shared/unused.yaml":    yamlHand("unused"),
+		"bot/nested/other.yaml": yamlHand("other"),
+	})
+
+	_, paths, err := LoadDescriptionsAndPaths(filepath.Join(dir, "bot"), EnvSecretsProvider{}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(dir, "bot"),
+		filepath.Join(dir, "shared", "extra.yaml"),
+		filepath.Join(dir, "teams"),
+	}
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected paths %q got %q", expected, paths)
+	}
+}
+
+func TestLoadDescriptionsErrors(t *testing.T) {
+	// проверяем ошибки загрузки описаний из нескольких файлов
+	dir, err := ioutil.TempDir("", "descriptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeDescriptions(t, dir, map[string]string{
+		"duplicates/a.yaml":  yamlHand("hand"),
+		"duplicates/b.yaml":  yamlHand("hand"),
+		"missing.yaml":       "include: [absent/*.yaml]\n" + yamlHand("main"),
+		"invalid/hand.yaml":  yamlHand("hand") + "  method: TRACE\n",
+		"broken/broken.yaml": "hand: [",
+		"broken_json/a.json": `{"hand": `,
+		"openapi.yaml":       "include: [specs/pets.yaml]\n" + yamlHand("main"),
+		"specs/pets.yaml":    petsOpenAPIDocument,
+	})
+
+	testCases := []struct {
+		Name  string
+		Path  string
+		Error string
+	}{
+		{
+			Name: "duplicate hands",
+			Path: filepath.Join(dir, "duplicates"),
+			Error: "Error(s) on processing entity : hand hand is described both in " +
+				filepath.Join(dir, "duplicates", "a.yaml") + " and " + filepath.Join(dir, "duplicates", "b.yaml") + "\n",
+		},
+		{
+			Name:  "missing include",
+			Path:  filepath.Join(dir, "missing.yaml"),
+			Error: "include absent/*.yaml in " + filepath.Join(dir, "missing.yaml") + " matches no files",
+		},
+		{
+			Name:  "validation error points to file",
+			Path:  filepath.Join(dir, "invalid"),
+			Error: "entity hand (" + filepath.Join(dir, "invalid", "hand.yaml") + "): unsupported method TRACE",
+		},
+		{
+			Name:  "broken file",
+			Path:  filepath.Join(dir, "broken"),
+			Error: "Failed to parse " + filepath.Join(dir, "broken", "broken.yaml"),
+		},
+		{
+			Name:  "broken file in directory",
+			Path:  filepath.Join(dir, "broken_json"),
+			Error: "Failed to parse " + filepath.Join(dir, "broken_json", "a.json") + ": unexpected end of JSON input",
+		},
+		{
+			Name:  "OpenAPI document in include",
+			Path:  filepath.Join(dir, "openapi.yaml"),
+			Error: "it's an OpenAPI document, add it to include_openapi",
+		},
+	}
+	for _, testCase := range testCases {
+		_, err := LoadDescriptionsFromPath(testCase.Path, EnvSecretsProvider{}, discardLogger())
+		if err == nil {
+			t.Errorf("%s: expected error %s", testCase.Name, testCase.Error)
+			continue
+		}
+		if !strings.Contains(err.Error(), testCase.Error) {
+			t.Errorf("%s: expected error %q got %q", testCase.Name, testCase.Error, err.Error())
+		}
+	}
+
+	_, err = GetDescriptionSourceFromYAML(strings.NewReader("include: [other.yaml]\n" + yamlHand("hand")))
+	if err != ErrIncludeWithoutPath {
+		t.Errorf("expected include error got %v", err)
+	}
+}
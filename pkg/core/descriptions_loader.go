@@ -1,14 +1,13 @@
 package core
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"strings"
-
-	"gopkg.in/yaml.v2"
+	"text/template"
 )
 
 // ValidationError error reporting about
@@ -51,8 +50,18 @@ func validateParam(paramInfo *ParamInfo) []error {
 	if (paramInfo.Destination == URLPlaced) && paramInfo.Optional && paramInfo.DefaultValue == nil {
 		errs = append(errs, errors.New("UrlPlaced param can't be marked as optional"))
 	}
-	if paramInfo.DefaultValue != nil {
-		val, err := parseFromInterface(paramInfo.Type, paramInfo.DefaultValue)
+	errs = append(errs, validateParamType(paramInfo)...)
+	constraintsErrs := validateConstraints(paramInfo)
+	errs = append(errs, constraintsErrs...)
+	if paramInfo.OptionsFrom != nil {
+		errs = append(errs, validateOptionsSource(paramInfo.OptionsFrom)...)
+	}
+	errs = append(errs, validateValueTemplate(paramInfo)...)
+	if paramInfo.DefaultValue != nil && !isTemplateValue(paramInfo.DefaultValue) {
+		val, err := parseFromInterface(*paramInfo, paramInfo.DefaultValue)
+		if err == nil && len(constraintsErrs) == 0 {
+			err = checkConstraints(paramInfo, val)
+		}
 		if err != nil {
 			errs = append(errs, fmt.Errorf("Error on default value %w", err))
 		} else {
@@ -62,29 +71,175 @@ func validateParam(paramInfo *ParamInfo) []error {
 	return errs
 }
 
+func validateParamType(paramInfo *ParamInfo) []error {
+	errs := make([]error, 0)
+	_, err := paramInfo.Type.ToString()
+	if err != nil {
+		return append(errs, err)
+	}
+	switch paramInfo.Type {
+	case EnumType:
+		if len(paramInfo.Choices) == 0 {
+			errs = append(errs, errors.New("enum param requires choices"))
+		}
+	case ListType:
+		elementType := paramInfo.GetElementType()
+		_, err := elementType.ToString()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid list element type %w", err))
+		}
+		if elementType == ListType {
+			errs = append(errs, errors.New("list of lists is not supported"))
+		}
+		if elementType == EnumType && len(paramInfo.Choices) == 0 {
+			errs = append(errs, errors.New("list of enum requires choices"))
+		}
+	}
+	return errs
+}
+
+func validateMethod(method string) error {
+	if method == "" {
+		return nil
+	}
+	for _, supported := range supportedMethods {
+		if strings.ToUpper(method) == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported method %s, expected one of %s", method, strings.Join(supportedMethods, ", "))
+}
+
+func validateRequestBody(urlRecord *URLRecord) []error {
+	errs := make([]error, 0)
+	requestBody := urlRecord.RequestBody
+	if requestBody == nil {
+		for paramName, param := range urlRecord.Parameters {
+			if param.Destination == BodyPlaced {
+				errs = append(errs, fmt.Errorf("body placed param %s requires request_body description", paramName))
+			}
+		}
+		return errs
+	}
+	method := urlRecord.GetMethod()
+	if method == http.MethodGet || method == http.MethodHead {
+		errs = append(errs, fmt.Errorf("request body can't be sent with %s method", method))
+	}
+	switch requestBody.GetContentType() {
+	case JSONContent, FormContent:
+	case TextContent:
+		if requestBody.Template == "" {
+			errs = append(errs, fmt.Errorf("request body with content type %s requires template", TextContent))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported request body content type %s", requestBody.ContentType))
+	}
+	return errs
+}
+
 func validateHand(urlRecord *URLRecord) []error {
 	errs := make([]error, 0)
+	err := validateMethod(urlRecord.Method)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateRequestBody(urlRecord)...)
+	err = validateResponseFormat(urlRecord.ResponseFormat)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if urlRecord.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout can't be negative"))
+	}
+	if urlRecord.Retries < 0 {
+		errs = append(errs, fmt.Errorf("retries number can't be negative"))
+	}
+	if urlRecord.RetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("retry backoff can't be negative"))
+	}
+	for _, status := range urlRecord.RetryOn {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("invalid status code %d in retry_on", status))
+		}
+	}
+	if urlRecord.Cache != nil {
+		if urlRecord.Cache.TTL <= 0 {
+			errs = append(errs, fmt.Errorf("cache ttl should be positive"))
+		}
+		// cached hand would skip repeated changes
+		if method := urlRecord.GetMethod(); method != http.MethodGet && method != http.MethodHead {
+			errs = append(errs, fmt.Errorf("cache can't be used with %s method, only GET and HEAD are cached", method))
+		}
+	}
+	for status := range urlRecord.StatusBodies {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("invalid status code %d in status bodies", status))
+		}
+	}
+	errs = append(errs, validateSteps(urlRecord)...)
+	if urlRecord.FanOut != nil {
+		errs = append(errs, validateFanOut(urlRecord)...)
+	}
+	if urlRecord.Pagination != nil {
+		errs = append(errs, validatePagination(urlRecord.Pagination)...)
+		if urlRecord.URLTemplate == "" {
+			errs = append(errs, fmt.Errorf("pagination requires url_template"))
+		}
+	}
+	if urlRecord.Auth != nil {
+		errs = append(errs, validateAuth(urlRecord.Auth)...)
+	}
+	for header, headerTemplate := range urlRecord.Headers {
+		_, err := template.New(header).Funcs(templateFuncs(nil)).Parse(headerTemplate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid template of header %s: %w", header, err))
+		}
+	}
 	for paramName, param := range urlRecord.Parameters {
 		handErrs := validateParam(&param)
+		handErrs = append(handErrs, validateConditions(paramName, &param, urlRecord.Parameters)...)
+		// only compiled pattern and parsed conditions are kept,
+		// default values are parsed on request
+		stored := urlRecord.Parameters[paramName]
+		stored.pattern = param.pattern
+		stored.visibleIf = param.visibleIf
+		stored.requiredIf = param.requiredIf
+		urlRecord.Parameters[paramName] = stored
 		if len(handErrs) != 0 {
 			err := newValidationError(paramName, handErrs)
 			errs = append(errs, err)
 		}
 	}
+	errs = append(errs, validateDependencies(urlRecord.Parameters)...)
 	return errs
 }
 
-func validateContainer(container *URLContrainer) error {
+func validateContainer(container *URLContrainer, secrets SecretsProvider) error {
 	errs := make([]error, 0)
 	for handName, hand := range *container {
-		handErrs := validateHand(&hand)
+		// secrets are resolved before validation of templates
+		handErrs := resolveSecrets(&hand, secrets)
+		(*container)[handName] = hand
+		handErrs = append(handErrs, validateHand(&hand)...)
 
 		if hand.URLName != handName {
 			handErrs = append(handErrs, fmt.Errorf("difference between hand name in field %s and in map %s", hand.URLName, handName))
 		}
+		for paramName, param := range hand.Parameters {
+			if param.OptionsFrom == nil || param.OptionsFrom.Hand == "" {
+				continue
+			}
+			if _, ok := (*container)[param.OptionsFrom.Hand]; !ok {
+				handErrs = append(handErrs, fmt.Errorf("options hand %s of param %s is not described", param.OptionsFrom.Hand, paramName))
+			}
+		}
 
 		if len(handErrs) != 0 {
-			err := newValidationError(handName, handErrs)
+			field := handName
+			if hand.Source != "" {
+				field = fmt.Sprintf("%s (%s)", handName, hand.Source)
+			}
+			err := newValidationError(field, handErrs)
 			errs = append(errs, err)
 		}
 	}
@@ -92,40 +247,54 @@ func validateContainer(container *URLContrainer) error {
 	return err
 }
 
-// GetDescriptionSourceFromJSON получить из json описание ручек
+// GetDescriptionSourceFromJSON получить из json описание ручек, секреты берутся из переменных окружения
 func GetDescriptionSourceFromJSON(reader io.Reader) (*SimpleDescriptionsSource, error) {
-	var urlContainer URLContrainer
+	return GetDescriptionSourceFromJSONWithSecrets(reader, EnvSecretsProvider{})
+}
+
+// GetDescriptionSourceFromJSONWithSecrets получить из json описание ручек, секреты берутся из secrets
+func GetDescriptionSourceFromJSONWithSecrets(reader io.Reader, secrets SecretsProvider) (*SimpleDescriptionsSource, error) {
 	// TODO: возможно стоит переделать на работу парсера, чтобы не вычитывать весь файл
 	bytes, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
-	err = json.Unmarshal(bytes, &urlContainer)
+	file, err := decodeJSONDescriptions(bytes)
 	if err != nil {
 		return nil, err
 	}
-	err = validateContainer(&urlContainer)
+	if file.hasIncludes() {
+		return nil, ErrIncludeWithoutPath
+	}
+	err = validateContainer(&file.hands, secrets)
 	if err != nil {
 		return nil, err
 	}
-	return NewDescriptionSourceFromDict(urlContainer), nil
+	return NewDescriptionSourceFromDict(file.hands), nil
 }
 
-// GetDescriptionSourceFromYAML получить из json описание ручек
+// GetDescriptionSourceFromYAML получить из yaml описание ручек, секреты берутся из переменных окружения
 func GetDescriptionSourceFromYAML(reader io.Reader) (*SimpleDescriptionsSource, error) {
-	var urlContainer URLContrainer
+	return GetDescriptionSourceFromYAMLWithSecrets(reader, EnvSecretsProvider{})
+}
+
+// GetDescriptionSourceFromYAMLWithSecrets получить из yaml описание ручек, секреты берутся из secrets
+func GetDescriptionSourceFromYAMLWithSecrets(reader io.Reader, secrets SecretsProvider) (*SimpleDescriptionsSource, error) {
 	// TODO: возможно стоит переделать на работу парсера, чтобы не вычитывать весь файл
 	bytes, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
-	err = yaml.Unmarshal(bytes, &urlContainer)
+	file, err := decodeYAMLDescriptions(bytes)
 	if err != nil {
 		return nil, err
 	}
-	err = validateContainer(&urlContainer)
+	if file.hasIncludes() {
+		return nil, ErrIncludeWithoutPath
+	}
+	err = validateContainer(&file.hands, secrets)
 	if err != nil {
 		return nil, err
 	}
-	return NewDescriptionSourceFromDict(urlContainer), nil
+	return NewDescriptionSourceFromDict(file.hands), nil
 }
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type DescriptionParsingResults struct {
@@ -243,6 +244,115 @@ func TestDescriptionsLoaderYAML(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "retries and timeouts",
+			Input: `ValuableName:
+  url_template: https://bash.im/entity
+  timeout: 5s
+  retries: 2
+  retry_backoff: 1
+  retry_on: [502, 503]
+  body: Value of Value is {{ .value }}
+  url_name: ValuableName
+  help: ""`,
+			Output: DescriptionParsingResults{
+				Container: URLContrainer{
+					"ValuableName": {
+						URLTemplate:  "https://bash.im/entity",
+						Timeout:      Duration(5 * time.Second),
+						Retries:      2,
+						RetryBackoff: Duration(time.Second),
+						RetryOn:      []int{502, 503},
+						Body:         "Value of Value is {{ .value }}",
+						URLName:      "ValuableName",
+					},
+				},
+				Err: nil,
+			},
+		},
+		{
+			Name: "options from unknown hand",
+			Input: `ValuableName:
+  url_template: https://bash.im/entity
+  parameters:
+    tenant:
+      name: tenant
+      destination: query
+      type: string
+      options_from:
+        hand: tenants
+        value_path: id
+  body: Value of Value is {{ .value }}
+  url_name: ValuableName
+  help: ""`,
+			Output: DescriptionParsingResults{
+				Container: URLContrainer{},
+				Err: &ValidationError{
+					Field: "",
+					WrappedError: []error{
+						&ValidationError{
+							Field: "ValuableName",
+							WrappedError: []error{
+								fmt.Errorf("options hand tenants of param tenant is not described"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "unsupported method",
+			Input: `ValuableName:
+  url_template: https://bash.im/entity
+  method: TRACE
+  body: Value of Value is {{ .value }}
+  url_name: ValuableName
+  help: ""`,
+			Output: DescriptionParsingResults{
+				Container: URLContrainer{},
+				Err: &ValidationError{
+					Field: "",
+					WrappedError: []error{
+						&ValidationError{
+							Field: "ValuableName",
+							WrappedError: []error{
+								fmt.Errorf("unsupported method TRACE, expected one of GET, POST, PUT, PATCH, DELETE, HEAD"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "body with get method",
+			Input: `ValuableName:
+  url_template: https://bash.im/entity
+  request_body:
+    content_type: application/json
+  parameters:
+    name:
+      help: Help to name
+      name: name
+      destination: body
+      type: string
+  body: Value of Value is {{ .value }}
+  url_name: ValuableName
+  help: ""`,
+			Output: DescriptionParsingResults{
+				Container: URLContrainer{},
+				Err: &ValidationError{
+					Field: "",
+					WrappedError: []error{
+						&ValidationError{
+							Field: "ValuableName",
+							WrappedError: []error{
+								fmt.Errorf("request body can't be sent with GET method"),
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	safeErrorPrint := func(errOut error) string {
 		if errOut == nil {
@@ -306,6 +416,98 @@ func TestParamsValidation(t *testing.T) {
 			},
 			Errors: "Error(s) on processing entity invalid_value: Error on default value strconv.Atoi: parsing \"a\": invalid syntax\n",
 		},
+		{
+			Param: ParamInfo{
+				Name:        "unknown_type",
+				Type:        "color",
+				Destination: QueryPlaced,
+			},
+			Errors: "Error(s) on processing entity unknown_type: Wrong parameter type color\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:        "enum_without_choices",
+				Type:        EnumType,
+				Destination: QueryPlaced,
+			},
+			Errors: "Error(s) on processing entity enum_without_choices: enum param requires choices\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:         "invalid_enum_default",
+				Type:         EnumType,
+				Destination:  QueryPlaced,
+				Choices:      []string{"eu", "us"},
+				DefaultValue: "asia",
+			},
+			Errors: "Error(s) on processing entity invalid_enum_default: Error on default value \"asia\" is not one of eu, us\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:         "list_of_dates",
+				Type:         ListType,
+				ElementType:  DateType,
+				Destination:  QueryPlaced,
+				DefaultValue: []interface{}{"2020-01-01", "2020-01-02"},
+			},
+			Errors: "",
+		},
+		{
+			Param: ParamInfo{
+				Name:        "list_of_lists",
+				Type:        ListType,
+				ElementType: ListType,
+				Destination: QueryPlaced,
+			},
+			Errors: "Error(s) on processing entity list_of_lists: list of lists is not supported\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:         "default_out_of_range",
+				Type:         IntegerType,
+				Destination:  QueryPlaced,
+				Max:          func(value float64) *float64 { return &value }(10),
+				DefaultValue: 20,
+			},
+			Errors: "Error(s) on processing entity default_out_of_range: Error on default value value 20 is greater than maximum 10\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:        "broken_pattern",
+				Type:        StringType,
+				Destination: QueryPlaced,
+				Pattern:     "[a-",
+			},
+			Errors: "Error(s) on processing entity broken_pattern: invalid pattern error parsing regexp: missing closing ]: `[a-`\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:         "templated_default",
+				Type:         DateTimeType,
+				Destination:  QueryPlaced,
+				DefaultValue: `{{ now | addTime "-1h" }}`,
+			},
+			Errors: "",
+		},
+		{
+			Param: ParamInfo{
+				Name:         "computed_with_default",
+				Type:         StringType,
+				Destination:  QueryPlaced,
+				Computed:     "{{ .meta.user.Login }}",
+				DefaultValue: "admin",
+			},
+			Errors: "Error(s) on processing entity computed_with_default: computed param can't have default value\n",
+		},
+		{
+			Param: ParamInfo{
+				Name:        "broken_computed",
+				Type:        StringType,
+				Destination: QueryPlaced,
+				Computed:    "{{ .meta.user.Login",
+			},
+			Errors: "Error(s) on processing entity broken_computed: invalid value template template: broken_computed:1: unclosed action\n",
+		},
 	}
 
 	for _, testCase := range testCases {
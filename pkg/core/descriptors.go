@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -19,27 +23,84 @@ import (
 type HandProcessorImp struct {
 	*URLRecord
 	client *http.Client
+	tokens *tokenStore
+	cache  ResponceCache
+	// query templated query params of hand step
+	query map[string]string
+	// page next page request of paginated hand
+	page *pageRequest
+	// parseMode markup of rendered output
+	parseMode ParseMode
 }
 
-func (processor *HandProcessorImp) compileTemplate(rec *URLRecord, _ map[string]interface{}) (*template.Template, error) {
+func (processor *HandProcessorImp) compileTemplate(rec *URLRecord, body string, data map[string]interface{}) (*template.Template, error) {
+	tmpl, err := template.New(rec.URLName).Funcs(templateFuncs(data)).Funcs(template.FuncMap{
+		escapeFuncName: escaper(processor.parseMode),
+	}).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if processor.parseMode != PlainMode {
+		escapeActions(tmpl)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate execute one-shot template on data
+func renderTemplate(name string, text string, data interface{}) (string, error) {
+	tmp, err := template.New(name).Funcs(templateFuncs(data)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	return executeToString(tmp, data)
+}
+
+// renderBodyTemplate execute request body template, values interpolated
+// into json body are escaped so they can't break it or add fields
+func renderBodyTemplate(name string, text string, contentType BodyContentType, data interface{}) (string, error) {
+	if contentType != JSONContent {
+		return renderTemplate(name, text, data)
+	}
+	tmp, err := template.New(name).Funcs(templateFuncs(data)).Funcs(jsonBodyFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	err = escapeJSONActions(tmp)
+	if err != nil {
+		return "", err
+	}
+	body, err := executeToString(tmp, data)
+	if err != nil {
+		return "", err
+	}
+	if !json.Valid([]byte(body)) {
+		return "", fmt.Errorf("rendered body is not valid json: %s", body)
+	}
+	return body, nil
+}
 
-	getValue := func(name string) string {
-		return ""
+// executeToString execute template on data into string
+func executeToString(tmp *template.Template, data interface{}) (string, error) {
+	var builder strings.Builder
+	err := tmp.Execute(&builder, data)
+	if err != nil {
+		return "", err
 	}
-	return template.New(rec.URLName).Funcs(
-		template.FuncMap{
-			"GetValue": getValue,
-		},
-	).Parse(rec.Body)
+	return builder.String(), nil
 }
 
 //NewHandProcessor build hand processor to current data
 func NewHandProcessor(rec *URLRecord, client *http.Client) (HandProcessor, error) {
-	imp := HandProcessorImp{
+	return newHandProcessor(rec, client, newTokenStore(), NewMemoryCache()), nil
+}
+
+func newHandProcessor(rec *URLRecord, client *http.Client, tokens *tokenStore, cache ResponceCache) *HandProcessorImp {
+	return &HandProcessorImp{
 		URLRecord: rec,
 		client:    client,
+		tokens:    tokens,
+		cache:     cache,
 	}
-	return &imp, nil
 }
 
 //WriteHelp write help to current data
@@ -48,10 +109,78 @@ func (processor *HandProcessorImp) WriteHelp(writer io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("Error while writing brief %w", err)
 	}
-	_, err = io.WriteString(writer, fmt.Sprintf("URL template: %s\n", processor.URLTemplate))
+	if processor.Source != "" {
+		_, err = io.WriteString(writer, fmt.Sprintf("Source: %s\n", processor.Source))
+		if err != nil {
+			return fmt.Errorf("Error while writing source %w", err)
+		}
+	}
+	_, err = io.WriteString(writer, fmt.Sprintf("URL template: %s\n", processor.maskSecrets(processor.URLTemplate)))
 	if err != nil {
 		return fmt.Errorf("Error while writing URL template %w", err)
 	}
+	_, err = io.WriteString(writer, fmt.Sprintf("Method: %s\n", processor.GetMethod()))
+	if err != nil {
+		return fmt.Errorf("Error while writing method %w", err)
+	}
+	if processor.RequestBody != nil {
+		_, err = io.WriteString(writer, fmt.Sprintf("Request body: %s\n", processor.RequestBody.GetContentType()))
+		if err != nil {
+			return fmt.Errorf("Error while writing request body %w", err)
+		}
+	}
+	if processor.Auth != nil {
+		_, err = io.WriteString(writer, fmt.Sprintf("Authentication: %s\n", processor.Auth.Type))
+		if err != nil {
+			return fmt.Errorf("Error while writing authentication %w", err)
+		}
+	}
+	if len(processor.Headers) != 0 {
+		_, err = io.WriteString(writer, "Headers:\n")
+		if err != nil {
+			return fmt.Errorf("Error while writing headers header %w", err)
+		}
+		var headers []string
+		for header := range processor.Headers {
+			headers = append(headers, header)
+		}
+		sort.Strings(headers)
+		for _, header := range headers {
+			_, err = io.WriteString(writer, fmt.Sprintf("\t%s: %s\n", header, processor.maskSecrets(processor.Headers[header])))
+			if err != nil {
+				return fmt.Errorf("Error while writing header %s: %w", header, err)
+			}
+		}
+	}
+	if processor.FanOut != nil {
+		targets := strings.Join(processor.FanOut.Targets, ", ")
+		if processor.FanOut.Param != "" {
+			targets = fmt.Sprintf("from param %s", processor.FanOut.Param)
+		}
+		_, err = io.WriteString(writer, fmt.Sprintf("Fan-out: %s\n", targets))
+		if err != nil {
+			return fmt.Errorf("Error while writing fan-out %w", err)
+		}
+	}
+	if processor.Pagination != nil {
+		_, err = io.WriteString(writer, fmt.Sprintf("Pagination: %s, up to %d pages\n", processor.Pagination.Type, processor.Pagination.GetMaxPages()))
+		if err != nil {
+			return fmt.Errorf("Error while writing pagination %w", err)
+		}
+	}
+	if len(processor.Steps) != 0 {
+		_, err = io.WriteString(writer, "Steps:\n")
+		if err != nil {
+			return fmt.Errorf("Error while writing steps header %w", err)
+		}
+		for i := range processor.Steps {
+			step := processor.stepRecord(&processor.Steps[i])
+			_, err = io.WriteString(writer, fmt.Sprintf("\t%s: %s %s\n", processor.Steps[i].Name, step.GetMethod(), step.URLTemplate))
+			if err != nil {
+				return fmt.Errorf("Error while writing step %s: %w", processor.Steps[i].Name, err)
+			}
+		}
+	}
 	_, err = io.WriteString(writer, fmt.Sprintf("Parameters:\n"))
 	if err != nil {
 		return fmt.Errorf("Error while writing URL parameters header %w", err)
@@ -98,57 +227,235 @@ func (processor *HandProcessorImp) addQueryParams(req *http.Request, params map[
 			//TODO: add reflection?
 			val, ok := params[name]
 			if ok {
-				qry.Add(name, fmt.Sprintf("%v", val))
+				for _, value := range paramStrings(val) {
+					qry.Add(name, value)
+				}
 			}
 		}
 	}
 	req.URL.RawQuery = qry.Encode()
 }
 
-func (processor *HandProcessorImp) mergeWithDefault(params map[string]interface{}, logger *log.Entry) {
+func (processor *HandProcessorImp) addHeaders(req *http.Request, params map[string]interface{}) error {
+	header, err := processor.renderHeaders(params)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return nil
+}
+
+// renderHeaders render static headers of the hand and header placed params
+func (processor *HandProcessorImp) renderHeaders(params map[string]interface{}) (http.Header, error) {
+	header := http.Header{}
+	for name, headerTemplate := range processor.URLRecord.Headers {
+		value, err := renderTemplate(name, headerTemplate, params)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to build header %s: %w", name, err)
+		}
+		header.Set(name, value)
+	}
+	for name, description := range processor.URLRecord.Parameters {
+		if description.Destination == HeaderPlaced {
+			val, ok := params[name]
+			if ok {
+				header.Set(name, fmt.Sprintf("%v", val))
+			}
+		}
+	}
+	return header, nil
+}
+
+func (processor *HandProcessorImp) getBodyParams(params map[string]interface{}) map[string]interface{} {
+	bodyParams := make(map[string]interface{})
+	for name, description := range processor.URLRecord.Parameters {
+		if description.Destination == BodyPlaced {
+			val, ok := params[name]
+			if ok {
+				bodyParams[name] = val
+			}
+		}
+	}
+	return bodyParams
+}
+
+// buildBody render request body and return it with it's content type
+func (processor *HandProcessorImp) buildBody(params map[string]interface{}) ([]byte, BodyContentType, error) {
+	requestBody := processor.URLRecord.RequestBody
+	if requestBody == nil {
+		return nil, "", nil
+	}
+	contentType := requestBody.GetContentType()
+	if requestBody.Template != "" {
+		body, err := renderBodyTemplate(processor.URLName, requestBody.Template, contentType, params)
+		if err != nil {
+			return nil, contentType, fmt.Errorf("Failed to build body %w", err)
+		}
+		return []byte(body), contentType, nil
+	}
+	bodyParams := processor.getBodyParams(params)
+	switch contentType {
+	case JSONContent:
+		body, err := json.Marshal(bodyParams)
+		if err != nil {
+			return nil, contentType, fmt.Errorf("Failed to encode json body %w", err)
+		}
+		return body, contentType, nil
+	case FormContent:
+		form := url.Values{}
+		for name, val := range bodyParams {
+			for _, value := range paramStrings(val) {
+				form.Add(name, value)
+			}
+		}
+		return []byte(form.Encode()), contentType, nil
+	}
+	return nil, contentType, fmt.Errorf("Can't build body with content type %s without template", contentType)
+}
+
+// mergeWithDefault fill missing params with default values
+// and computed values, values of hidden params are dropped
+func (processor *HandProcessorImp) mergeWithDefault(ctx context.Context, params map[string]interface{}, logger *log.Entry) error {
 	defaultValues := processor.GetParamsDefaultValues()
 	for paramName, defaultValue := range defaultValues {
 		_, hasValue := params[paramName]
 		if !hasValue {
 			params[paramName] = defaultValue
-			logger.Debugf("using default value %v for param %s", defaultValue, paramName)
+			logger.Debugf("using default value %s for param %s", processor.maskSecrets(fmt.Sprintf("%v", defaultValue)), paramName)
+		}
+	}
+	err := processor.computeValues(ctx, params, logger)
+	if err != nil {
+		return err
+	}
+	for _, paramName := range processor.hiddenParams(params) {
+		if _, hasValue := params[paramName]; hasValue {
+			delete(params, paramName)
+			logger.Debugf("dropping value of hidden param %s", paramName)
 		}
 	}
+	return nil
 }
 
-//Process load data from hand url and
-//execute template with it
-func (processor *HandProcessorImp) Process(ctx context.Context, writer io.Writer, params map[string]interface{}, logger *log.Entry) error {
-	processor.mergeWithDefault(params, logger)
-	url := new(bytes.Buffer)
-	tmp, err := template.New(processor.URLName).Parse(processor.URLTemplate)
+// handResponce responce of the hand request
+type handResponce struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Data    interface{}
+	Elapsed time.Duration
+	// Pages number of fetched pages of paginated hand
+	Pages int
+}
+
+// renderURL build request url from url template without query params
+func (processor *HandProcessorImp) renderURL(params map[string]interface{}) (string, error) {
+	if processor.page != nil && processor.page.url != "" {
+		return processor.page.url, nil
+	}
+	requestURL := new(bytes.Buffer)
+	tmp, err := template.New(processor.URLName).Funcs(templateFuncs(params)).Parse(processor.URLTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Failed to build URL template %w", err)
+	}
+
+	err = tmp.Execute(requestURL, params)
 	if err != nil {
-		return fmt.Errorf("Failed to build URL template %w", err)
+		return "", fmt.Errorf("Failed to build URL %w", err)
 	}
+	return requestURL.String(), nil
+}
 
-	err = tmp.Execute(url, params)
+// buildRequest build request with all parameters placed, request url
+// without auth credentials is returned, it's masked before shown to user
+func (processor *HandProcessorImp) buildRequest(ctx context.Context, params map[string]interface{}, header http.Header) (*http.Request, string, error) {
+	requestURL, err := processor.renderURL(params)
 	if err != nil {
-		return fmt.Errorf("Failed to build URL %w", err)
+		return nil, "", err
 	}
-	logger.Debugf("Got URL %s", url.String())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
+	body, contentType, err := processor.buildBody(params)
 	if err != nil {
-		return fmt.Errorf("Failed to build request %w", err)
+		return nil, "", err
 	}
-	processor.addQueryParams(req, params)
 
-	logger.Debugf("Got request %s", func() string {
-		bytes, err := httputil.DumpRequest(req, true)
+	req, err := http.NewRequestWithContext(ctx, processor.GetMethod(), requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to build request %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", string(contentType))
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if processor.page == nil || processor.page.url == "" {
+		// next page link already contains all query params
+		processor.addQueryParams(req, params)
+		err = renderQuery(req, processor.query, params)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	processor.page.apply(req)
+	err = processor.addHeaders(req, params)
+	if err != nil {
+		return nil, "", err
+	}
+	// url is saved before auth to keep api keys out of templates
+	requestURL = req.URL.String()
+	if processor.Auth != nil {
+		err = applyAuth(ctx, req, processor.Auth, processor.client, processor.tokens)
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed to authenticate request %w", err)
+		}
+	}
+	return req, requestURL, nil
+}
+
+// dumpRequest dump request for logs with credentials of hand auth
+// and interpolated secrets masked
+func (processor *HandProcessorImp) dumpRequest(req *http.Request) string {
+	redacted := req.Clone(req.Context())
+	if req.GetBody != nil {
+		// body is shared with original request and would be read by dump
+		body, err := req.GetBody()
 		if err != nil {
 			return err.Error()
 		}
-		return string(bytes)
-	}())
+		redacted.Body = body
+	}
+	if auth := processor.Auth; auth != nil {
+		if redacted.Header.Get("Authorization") != "" {
+			redacted.Header.Set("Authorization", maskedSecret)
+		}
+		if auth.Type == APIKeyAuth && auth.GetKeyDestination() == QueryPlaced {
+			qry := redacted.URL.Query()
+			qry.Set(auth.Name, maskedSecret)
+			redacted.URL.RawQuery = qry.Encode()
+		} else if auth.Type == APIKeyAuth {
+			redacted.Header.Set(auth.Name, maskedSecret)
+		}
+	}
+	dump, err := httputil.DumpRequest(redacted, true)
+	if err != nil {
+		return err.Error()
+	}
+	return processor.maskSecrets(string(dump))
+}
+
+// send send request and read it's responce
+func (processor *HandProcessorImp) send(req *http.Request, logger *log.Entry) (*handResponce, error) {
+	if logger.Logger.IsLevelEnabled(log.DebugLevel) {
+		logger.Debugf("Got request %s", processor.dumpRequest(req))
+	}
 
+	started := time.Now()
 	responce, err := processor.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("Failed to read result %w", err)
+		return nil, fmt.Errorf("Failed to read result %w", err)
 	}
 
 	defer responce.Body.Close()
@@ -160,23 +467,287 @@ func (processor *HandProcessorImp) Process(ctx context.Context, writer io.Writer
 		return string(bytes)
 	}())
 
-	responceData := make(map[string]interface{})
-	err = json.NewDecoder(responce.Body).Decode(&responceData)
+	responceBody, err := ioutil.ReadAll(responce.Body)
 	if err != nil {
-		return fmt.Errorf("Failed to decode json result %w", err)
+		return nil, fmt.Errorf("Failed to read result body %w", err)
 	}
+	return &handResponce{
+		Status:  responce.StatusCode,
+		Header:  responce.Header,
+		Body:    responceBody,
+		Elapsed: time.Since(started),
+	}, nil
+}
+
+// tokenRejected check if upstream rejected cached oauth2 token,
+// it can be revoked before expiration, rejected token is dropped
+func (processor *HandProcessorImp) tokenRejected(req *http.Request, responce *handResponce) bool {
+	if processor.Auth == nil || processor.Auth.Type != OAuth2Auth || responce.Status != http.StatusUnauthorized {
+		return false
+	}
+	processor.tokens.dropToken(processor.Auth, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	return true
+}
+
+func (processor *HandProcessorImp) shouldRetry(responce *handResponce) bool {
+	if responce == nil {
+		return true
+	}
+	for _, status := range processor.GetRetryOn() {
+		if responce.Status == status {
+			return true
+		}
+	}
+	return false
+}
 
-	template, err := processor.compileTemplate(processor.URLRecord, params)
+// sendWithRetries build and send request, failed attempts are retried
+// with growing delay, each attempt is limited with hand timeout,
+// url of the request is returned unmasked
+func (processor *HandProcessorImp) sendWithRetries(ctx context.Context, params map[string]interface{}, header http.Header, logger *log.Entry) (*handResponce, string, error) {
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, processor.GetTimeout())
+		req, requestURL, err := processor.buildRequest(attemptCtx, params, header)
+		if err != nil {
+			cancel()
+			return nil, "", err
+		}
+		displayURL := processor.maskSecrets(requestURL)
+		logger.Debugf("Got URL %s", displayURL)
+		responce, err := processor.send(req, logger)
+		if err == nil && processor.tokenRejected(req, responce) {
+			logger.Debugf("Token is rejected by %s, retrying with a new one", displayURL)
+			req, _, err = processor.buildRequest(attemptCtx, params, header)
+			if err != nil {
+				cancel()
+				return nil, "", err
+			}
+			responce, err = processor.send(req, logger)
+		}
+		cancel()
+		if attempt >= processor.Retries || !processor.shouldRetry(responce) || ctx.Err() != nil {
+			return responce, requestURL, err
+		}
+		if !processor.CanRetry() {
+			logger.Debugf("Failed %s request to %s isn't retried without retry_unsafe", processor.GetMethod(), displayURL)
+			return responce, requestURL, err
+		}
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = fmt.Sprintf("status %d", responce.Status)
+		}
+		backoff := processor.GetRetryBackoff(attempt)
+		logger.Warnf("Attempt %d of %d to %s failed: %s, retrying in %s", attempt+1, processor.Retries+1, displayURL, reason, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, "", fmt.Errorf("Canceled while waiting for retry %w", ctx.Err())
+		}
+	}
+}
+
+// sendCached get responce from cache if hand is cached and stored responce
+// is fresh, stale responces are revalidated with conditional request
+func (processor *HandProcessorImp) sendCached(ctx context.Context, params map[string]interface{}, logger *log.Entry) (*handResponce, string, bool, error) {
+	if processor.URLRecord.Cache == nil || processor.cache == nil {
+		responce, requestURL, err := processor.sendWithRetries(ctx, params, nil, logger)
+		return responce, requestURL, false, err
+	}
+	ttl := time.Duration(processor.URLRecord.Cache.TTL)
+	resolvedURL, err := processor.renderURL(params)
 	if err != nil {
-		return fmt.Errorf("Failed to build request %w", err)
+		return nil, "", false, err
+	}
+	resolvedURL += processor.page.cacheSuffix()
+	renderedHeader, err := processor.renderHeaders(params)
+	if err != nil {
+		return nil, "", false, err
+	}
+	identity, err := authIdentity(processor.Auth)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("Failed to authenticate request %w", err)
+	}
+	key, err := cacheKey(processor.URLName, processor.GetMethod(), resolvedURL, params, renderedHeader, identity)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("Failed to build cache key %w", err)
+	}
+
+	var header http.Header
+	stored, ok := processor.cache.Get(key)
+	if ok {
+		if stored.isFresh(ttl) {
+			logger.Debugf("Using cached responce for %s", resolvedURL)
+			requestURL, err := processor.requestURL(params)
+			return cachedToHandResponce(stored), requestURL, true, err
+		}
+		if stored.hasValidators() {
+			header = conditionalHeaders(stored)
+		}
+	}
+
+	responce, requestURL, err := processor.sendWithRetries(ctx, params, header, logger)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if ok && responce.Status == http.StatusNotModified {
+		logger.Debugf("Cached responce for %s revalidated", resolvedURL)
+		revalidated := *stored
+		revalidated.StoredAt = time.Now()
+		processor.storeResponce(key, &revalidated, ttl)
+		return cachedToHandResponce(&revalidated), requestURL, true, nil
+	}
+	if responce.Status >= http.StatusOK && responce.Status < http.StatusMultipleChoices {
+		processor.storeResponce(key, &CachedResponce{
+			Status:   responce.Status,
+			Header:   responce.Header,
+			Body:     responce.Body,
+			StoredAt: time.Now(),
+		}, ttl)
+	}
+	return responce, requestURL, false, nil
+}
+
+func (processor *HandProcessorImp) storeResponce(key string, responce *CachedResponce, ttl time.Duration) {
+	lifetime := ttl
+	if responce.hasValidators() {
+		lifetime += staleCacheLifetime
+	}
+	processor.cache.Set(key, responce, lifetime)
+}
+
+// requestURL build url of the request without sending it
+func (processor *HandProcessorImp) requestURL(params map[string]interface{}) (string, error) {
+	req, err := http.NewRequest(processor.GetMethod(), "", nil)
+	if err != nil {
+		return "", err
+	}
+	requestURL, err := processor.renderURL(params)
+	if err != nil {
+		return "", err
+	}
+	req.URL, err = url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse URL %w", err)
+	}
+	if processor.page == nil || processor.page.url == "" {
+		processor.addQueryParams(req, params)
+	}
+	processor.page.apply(req)
+	return req.URL.String(), nil
+}
+
+func cachedToHandResponce(stored *CachedResponce) *handResponce {
+	return &handResponce{
+		Status: stored.Status,
+		Header: stored.Header,
+		Body:   stored.Body,
+	}
+}
+
+// decode decode responce body according to hand responce format
+func (processor *HandProcessorImp) decode(responce *handResponce, logger *log.Entry) error {
+	responceData, err := decodeResponce(processor.ResponseFormat, responce.Header.Get("Content-Type"), responce.Body)
+	if err != nil {
+		if !isErrorStatus(responce.Status) {
+			return err
+		}
+		// error pages are often not in the format of successful responce
+		logger.Debugf("Failed to decode error responce, using it as text: %s", err.Error())
+		responceData = string(responce.Body)
+	}
+	responce.Data = responceData
+	return nil
+}
+
+func isErrorStatus(status int) bool {
+	return status >= http.StatusBadRequest
+}
+
+// getBodyTemplate choose template for responce status:
+// template for exact status, error template for failed requests
+// or common body template
+func (processor *HandProcessorImp) getBodyTemplate(status int) string {
+	if body, ok := processor.StatusBodies[status]; ok {
+		return body
+	}
+	if isErrorStatus(status) && processor.ErrorBody != "" {
+		return processor.ErrorBody
+	}
+	return processor.Body
+}
+
+// flattenHeaders join multiple header values to use headers in templates
+func flattenHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		result[name] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+//Process load data from hand url and
+//execute template with it
+func (processor *HandProcessorImp) Process(ctx context.Context, writer io.Writer, params map[string]interface{}, logger *log.Entry) error {
+	err := processor.mergeWithDefault(ctx, params, logger)
+	if err != nil {
+		return err
+	}
+	if processor.FanOut != nil {
+		return processor.processFanOut(ctx, writer, params, logger)
 	}
 
+	steps, lastStep, err := processor.runSteps(ctx, params, logger)
+	if err != nil {
+		return err
+	}
+
+	var responce *handResponce
+	var displayURL string
+	var cached bool
+	if processor.URLTemplate == "" || (lastStep != nil && isErrorStatus(lastStep.responce.Status)) {
+		// hand result is the result of the last step
+		if lastStep == nil {
+			return fmt.Errorf("Hand %s has neither url template nor steps", processor.URLName)
+		}
+		responce = lastStep.responce
+		displayURL = lastStep.displayURL
+	} else {
+		requestParams := params
+		if len(processor.Steps) != 0 {
+			requestParams = withSteps(params, steps)
+		}
+		responce, displayURL, cached, err = processor.fetch(ctx, requestParams, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	meta := map[string]interface{}{
+		"url":     displayURL,
+		"params":  params,
+		"status":  responce.Status,
+		"headers": flattenHeaders(responce.Header),
+		"elapsed": responce.Elapsed,
+		"cached":  cached,
+		"pages":   responce.Pages,
+	}
+	addUser(ctx, meta)
 	templateData := map[string]interface{}{
-		"responce": responceData,
-		"meta": map[string]interface{}{
-			"url":    req.URL.String(),
-			"params": params,
-		},
+		"responce": responce.Data,
+		"meta":     meta,
+		stepsKey:   steps,
+	}
+
+	return processor.render(writer, processor.getBodyTemplate(responce.Status), templateData)
+}
+
+// render execute body template with data
+func (processor *HandProcessorImp) render(writer io.Writer, body string, templateData map[string]interface{}) error {
+	template, err := processor.compileTemplate(processor.URLRecord, body, templateData)
+	if err != nil {
+		return fmt.Errorf("Failed to build request %w", err)
 	}
 
 	err = template.Lookup(processor.URLRecord.URLName).Execute(writer, templateData)
@@ -199,6 +770,7 @@ func (processor *HandProcessorImp) GetParam(paramName string) (ParamProcessor, e
 		return nil, ErrNonExistentParam
 	}
 	param := NewParamProcessor(paramValue)
+	param.secretValues = processor.secretValues
 	return &param, nil
 }
 
@@ -218,6 +790,21 @@ func (processor *HandProcessorImp) GetRequiredParams() ([]ParamProcessor, error)
 	return result, nil
 }
 
+//GetRequiredParamsFor get all parameters required with current values
+func (processor *HandProcessorImp) GetRequiredParamsFor(values map[string]interface{}) ([]ParamProcessor, error) {
+	result := make([]ParamProcessor, 0)
+	for paramName := range processor.Parameters {
+		param, err := processor.GetParam(paramName)
+		if err != nil {
+			return result, err
+		}
+		if param.IsRequiredFor(values) {
+			result = append(result, param)
+		}
+	}
+	return result, nil
+}
+
 //GetParams get all parameters
 func (processor *HandProcessorImp) GetParams() (map[string]ParamProcessor, error) {
 	result := make(map[string]ParamProcessor)
@@ -231,12 +818,18 @@ func (processor *HandProcessorImp) GetParams() (map[string]ParamProcessor, error
 	return result, nil
 }
 
-//GetParamsDefaultValues get all default parameters values
+//GetParamsDefaultValues get all static default parameters values
 func (processor *HandProcessorImp) GetParamsDefaultValues() map[string]interface{} {
 	result := make(map[string]interface{})
 	for paramName, param := range processor.Parameters {
-		if param.DefaultValue != nil {
-			result[paramName] = param.DefaultValue
+		// templated values are evaluated on execution
+		if param.DefaultValue != nil && !isTemplateValue(param.DefaultValue) {
+			value, err := parseFromInterface(param, param.DefaultValue)
+			if err != nil {
+				// default values are checked on loading
+				value = param.DefaultValue
+			}
+			result[paramName] = value
 		}
 	}
 	return result
@@ -245,11 +838,13 @@ func (processor *HandProcessorImp) GetParamsDefaultValues() map[string]interface
 //ParamProcessorImp implemet
 type ParamProcessorImp struct {
 	ParamInfo
+	// secretValues values of secrets hidden in help
+	secretValues []string
 }
 
 //NewParamProcessor build new parameter processor by info
 func NewParamProcessor(info ParamInfo) ParamProcessorImp {
-	return ParamProcessorImp{info}
+	return ParamProcessorImp{ParamInfo: info}
 }
 
 //WriteHelp writs help
@@ -275,7 +870,13 @@ func (p *ParamProcessorImp) WriteHelp(writer io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
 	}
-	if !p.IsRequired() {
+	if p.RequiredIf != "" {
+		// param is optional only while condition doesn't hold
+		_, err = io.WriteString(writer, "\t[Required if: "+p.RequiredIf+"]")
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	} else if !p.IsRequired() {
 		_, err = io.WriteString(writer, "\t[Optional]")
 		if err != nil {
 			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
@@ -286,7 +887,38 @@ func (p *ParamProcessorImp) WriteHelp(writer io.Writer) error {
 		return fmt.Errorf("Failed to write \n 2 help for %s: %w", p.Name, err)
 	}
 	if p.DefaultValue != nil {
-		_, err = io.WriteString(writer, fmt.Sprintf("\tDefault: %v\n", p.DefaultValue))
+		_, err = io.WriteString(writer, fmt.Sprintf("\tDefault: %s\n", maskSecretValues(p.secretValues, fmt.Sprintf("%v", p.DefaultValue))))
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	}
+	if p.Computed != "" {
+		_, err = io.WriteString(writer, "\tComputed: "+maskSecretValues(p.secretValues, p.Computed)+"\n")
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	}
+	details := p.typeDetails()
+	if details != "" {
+		_, err = io.WriteString(writer, "\t"+details+"\n")
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	}
+	if p.hasConstraints() {
+		_, err = io.WriteString(writer, "\tConstraints: "+p.constraintsHelp()+"\n")
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	}
+	if p.OptionsFrom != nil {
+		_, err = io.WriteString(writer, "\tOptions from: "+p.OptionsFrom.String()+"\n")
+		if err != nil {
+			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
+		}
+	}
+	for _, line := range p.conditionsHelp() {
+		_, err = io.WriteString(writer, "\t"+line+"\n")
 		if err != nil {
 			return fmt.Errorf("Failed to write help for %s: %w", p.Name, err)
 		}
@@ -298,14 +930,45 @@ func (p *ParamProcessorImp) WriteHelp(writer io.Writer) error {
 	return nil
 }
 
+// typeDetails describe expected input format of the param
+func (p *ParamProcessorImp) typeDetails() string {
+	switch p.Type {
+	case DateType, DateTimeType:
+		return fmt.Sprintf("Format: %s", p.GetLayout())
+	case DurationType:
+		return "Format: 1h30m"
+	case BoolType:
+		return "Values: true, false"
+	case EnumType:
+		return fmt.Sprintf("Choices: %s", strings.Join(p.Choices, ", "))
+	case ListType:
+		elementType, err := p.GetElementType().ToString()
+		if err != nil {
+			return ""
+		}
+		details := fmt.Sprintf("List of %s separated by %q", elementType, p.GetSeparator())
+		element := p.elementInfo()
+		elementProcessor := NewParamProcessor(element)
+		if elementDetails := elementProcessor.typeDetails(); elementDetails != "" {
+			details += ", " + elementDetails
+		}
+		return details
+	}
+	return ""
+}
+
 //GetInfo get raw info
 func (p *ParamProcessorImp) GetInfo() ParamInfo {
 	return p.ParamInfo
 }
 
-//IsRequired check if parameter is required
+//IsRequired check if parameter is required regardless of other params values
 func (p *ParamProcessorImp) IsRequired() bool {
-	return (p.Destination == URLPlaced && p.DefaultValue == nil) || (p.Destination == QueryPlaced && (!p.Optional && p.DefaultValue == nil))
+	if p.DefaultValue != nil || p.Computed != "" || p.RequiredIf != "" || p.VisibleIf != "" {
+		return false
+	}
+	// URL placed params can't be skipped
+	return p.Destination == URLPlaced || !p.Optional
 }
 
 func parseString(str string) (interface{}, error) {
@@ -316,33 +979,71 @@ func parseInt(str string) (interface{}, error) {
 	return strconv.Atoi(str)
 }
 
-func parseFromInterface(tp ParamType, val interface{}) (interface{}, error) {
-	switch valType := val.(type) {
-	case int:
-		{
-			return val, nil
-		}
+func parseFromInterface(info ParamInfo, val interface{}) (interface{}, error) {
+	switch value := val.(type) {
 	case string:
-		{
-			return parseValue(tp, val.(string))
+		return parseValue(info, value)
+	case TimeValue, ListValue, Duration:
+		return val, nil
+	case []interface{}:
+		if info.Type != ListType {
+			return nil, fmt.Errorf("failed to get %v from list %v", info.Type, val)
+		}
+		element := info.elementInfo()
+		result := make(ListValue, 0, len(value))
+		for _, elementValue := range value {
+			parsed, err := parseFromInterface(element, elementValue)
+			if err != nil {
+				return nil, fmt.Errorf("invalid list element %w", err)
+			}
+			result = append(result, parsed)
 		}
-	default:
-		return nil, fmt.Errorf("failed to get %v from param %v with type %v", tp, val, valType)
+		return result, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		return nil, fmt.Errorf("failed to get %v from param %v with type %T", info.Type, val, val)
+	}
+	switch info.Type {
+	case ListType:
+		return parseFromInterface(info, []interface{}{val})
+	case DurationType:
+		// numbers are treated as a number of seconds
+		return parseDuration(val)
 	}
+	return parseValue(info, fmt.Sprintf("%v", val))
 }
 
-func parseValue(tp ParamType, str string) (interface{}, error) {
-	switch tp {
+func parseValue(info ParamInfo, str string) (interface{}, error) {
+	switch info.Type {
 	case StringType:
 		return parseString(str)
 	case IntegerType:
 		return parseInt(str)
+	case FloatType:
+		return parseFloat(str)
+	case BoolType:
+		return parseBool(str)
+	case DateType, DateTimeType:
+		return parseDate(info, str)
+	case DurationType:
+		return parseDurationParam(str)
+	case EnumType:
+		return parseEnum(info, str)
+	case ListType:
+		return parseList(info, str)
 	}
 	//TODO: make a new good errors
-	return nil, fmt.Errorf("Unknown type %s", tp)
+	return nil, fmt.Errorf("Unknown type %s", info.Type)
 }
 
 //ParseFromString get param value from string
 func (p *ParamProcessorImp) ParseFromString(str string) (interface{}, error) {
-	return parseValue(p.Type, str)
+	value, err := parseValue(p.ParamInfo, str)
+	if err != nil {
+		return nil, err
+	}
+	err = checkConstraints(&p.ParamInfo, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
 }
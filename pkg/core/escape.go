@@ -0,0 +1,246 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ParseMode markup of messages the hand output is rendered into,
+// it's used to escape values interpolated into body templates
+type ParseMode string
+
+const (
+	//PlainMode output has no markup, values are not escaped
+	PlainMode ParseMode = ""
+	//MarkdownMode telegram legacy Markdown markup
+	MarkdownMode ParseMode = "Markdown"
+	//MarkdownV2Mode telegram MarkdownV2 markup
+	MarkdownV2Mode ParseMode = "MarkdownV2"
+	//HTMLMode telegram HTML markup
+	HTMLMode ParseMode = "HTML"
+)
+
+// escapeFuncName name of the function appended to each body template action
+const escapeFuncName = "escape"
+
+// escapeValueFuncName name of the function appended to json request
+// body template actions placed outside of strings
+const escapeValueFuncName = "escapeValue"
+
+var escapers = map[ParseMode]*strings.Replacer{
+	MarkdownMode: strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`),
+	MarkdownV2Mode: strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	),
+	HTMLMode: strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;"),
+}
+
+// SafeString value which is inserted into body template without escaping
+type SafeString string
+
+// raw mark value as safe to keep it's markup
+func raw(value interface{}) SafeString {
+	if safe, ok := value.(SafeString); ok {
+		return safe
+	}
+	return SafeString(toString(value))
+}
+
+// escaper build function escaping values for parse mode,
+// values marked as safe are kept as is
+func escaper(mode ParseMode) func(value interface{}) SafeString {
+	replacer, ok := escapers[mode]
+	return func(value interface{}) SafeString {
+		if !ok {
+			return raw(value)
+		}
+		if safe, isSafe := value.(SafeString); isSafe {
+			return safe
+		}
+		return SafeString(replacer.Replace(toString(value)))
+	}
+}
+
+// escapeJSON escape value placed into json string of request body
+// template, values marked as safe and built by toJson are kept as is
+func escapeJSON(value interface{}) SafeString {
+	if safe, isSafe := value.(SafeString); isSafe {
+		return safe
+	}
+	quoted, err := json.Marshal(toString(value))
+	if err != nil {
+		return SafeString(toString(value))
+	}
+	return SafeString(quoted[1 : len(quoted)-1])
+}
+
+// escapeJSONValue escape value placed into json request body template
+// outside of string, values which are valid json themselves (numbers,
+// quoted strings, toJson results) are kept as is, others are encoded
+// as json string so they can't add fields to the body
+func escapeJSONValue(value interface{}) (SafeString, error) {
+	if safe, isSafe := value.(SafeString); isSafe {
+		return safe, nil
+	}
+	text := toString(value)
+	if json.Valid([]byte(text)) {
+		return SafeString(text), nil
+	}
+	quoted, err := json.Marshal(text)
+	return SafeString(quoted), err
+}
+
+// jsonBodyFuncs functions of json request body template, toJson
+// result is already valid json and it isn't escaped
+var jsonBodyFuncs = template.FuncMap{
+	escapeFuncName:      escapeJSON,
+	escapeValueFuncName: escapeJSONValue,
+	"toJson": func(value interface{}) (SafeString, error) {
+		result, err := toJSON(value)
+		return SafeString(result), err
+	},
+	"toPrettyJson": func(value interface{}) (SafeString, error) {
+		result, err := toPrettyJSON(value)
+		return SafeString(result), err
+	},
+}
+
+func validateParseMode(mode ParseMode) error {
+	switch mode {
+	case PlainMode, MarkdownMode, MarkdownV2Mode, HTMLMode:
+		return nil
+	}
+	return fmt.Errorf("unsupported parse mode %s", mode)
+}
+
+// escapeActions append escape command to every action printing a value,
+// so all interpolated values are escaped unless they are marked as safe
+func escapeActions(tmpl *template.Template) {
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree != nil {
+			escapeList(defined.Tree.Root)
+		}
+	}
+}
+
+func escapeList(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch typed := node.(type) {
+		case *parse.ActionNode:
+			appendEscape(typed.Pipe, escapeFuncName)
+		case *parse.IfNode:
+			escapeList(typed.List)
+			escapeList(typed.ElseList)
+		case *parse.RangeNode:
+			escapeList(typed.List)
+			escapeList(typed.ElseList)
+		case *parse.WithNode:
+			escapeList(typed.List)
+			escapeList(typed.ElseList)
+		case *parse.ListNode:
+			escapeList(typed)
+		}
+	}
+}
+
+// appendEscape append funcName command to action printing a value
+func appendEscape(pipe *parse.PipeNode, funcName string) {
+	if len(pipe.Decl) != 0 {
+		// variable declaration prints nothing
+		return
+	}
+	pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pipe.Pos,
+		Args:     []parse.Node{parse.NewIdentifier(funcName).SetPos(pipe.Pos)},
+	})
+}
+
+// escapeJSONActions append escape command to every action of json body
+// template, actions inside of json strings are escaped as string content,
+// others as json values, defined templates are expected outside of strings
+func escapeJSONActions(tmpl *template.Template) error {
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+		_, err := escapeJSONList(defined.Tree.Root, false)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", defined.Name(), err)
+		}
+	}
+	return nil
+}
+
+// escapeJSONList escape actions of the list which starts inside of json
+// string or outside of it, returns whether the list ends inside of string
+func escapeJSONList(list *parse.ListNode, inString bool) (bool, error) {
+	if list == nil {
+		return inString, nil
+	}
+	var err error
+	for _, node := range list.Nodes {
+		switch typed := node.(type) {
+		case *parse.TextNode:
+			inString = scanJSONText(typed.Text, inString)
+		case *parse.ActionNode:
+			if inString {
+				appendEscape(typed.Pipe, escapeFuncName)
+			} else {
+				appendEscape(typed.Pipe, escapeValueFuncName)
+			}
+		case *parse.IfNode:
+			inString, err = escapeJSONBranches(&typed.BranchNode, inString, false)
+		case *parse.RangeNode:
+			inString, err = escapeJSONBranches(&typed.BranchNode, inString, true)
+		case *parse.WithNode:
+			inString, err = escapeJSONBranches(&typed.BranchNode, inString, false)
+		case *parse.ListNode:
+			inString, err = escapeJSONList(typed, inString)
+		}
+		if err != nil {
+			return inString, err
+		}
+	}
+	return inString, nil
+}
+
+// escapeJSONBranches escape actions of if, with or range branches, all of
+// them have to leave json string state unchanged or equal to each other
+func escapeJSONBranches(branch *parse.BranchNode, inString bool, loop bool) (bool, error) {
+	listState, err := escapeJSONList(branch.List, inString)
+	if err != nil {
+		return inString, err
+	}
+	elseState, err := escapeJSONList(branch.ElseList, inString)
+	if err != nil {
+		return inString, err
+	}
+	if listState != elseState || (loop && listState != inString) {
+		return inString, fmt.Errorf("json string quotes are unbalanced in %s", branch.String())
+	}
+	return listState, nil
+}
+
+// scanJSONText find whether text of json body template ends inside of string
+func scanJSONText(text []byte, inString bool) bool {
+	escaped := false
+	for _, char := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && char == '\\':
+			escaped = true
+		case char == '"':
+			inString = !inString
+		}
+	}
+	return inString
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	// проверяем экранирование значений в шаблоне ответа для разных режимов разметки
+	data := map[string]interface{}{
+		"responce": map[string]interface{}{
+			"name":  "a_b*c <d> & `e` [f]",
+			"items": []interface{}{"x_1", "y_2"},
+		},
+	}
+	testCases := []struct {
+		Mode     ParseMode
+		Template string
+		Output   string
+	}{
+		{
+			Mode:     PlainMode,
+			Template: `*{{ .responce.name }}*`,
+			Output:   "*a_b*c <d> & `e` [f]*",
+		},
+		{
+			Mode:     MarkdownMode,
+			Template: `*{{ .responce.name }}*`,
+			Output:   "*a\\_b\\*c <d> & \\`e\\` \\[f]*",
+		},
+		{
+			Mode:     HTMLMode,
+			Template: `<b>{{ .responce.name }}</b>`,
+			Output:   "<b>a_b*c &lt;d&gt; &amp; `e` [f]</b>",
+		},
+		{
+			Mode:     MarkdownV2Mode,
+			Template: `{{ "1.5 (x)!" }}`,
+			Output:   `1\.5 \(x\)\!`,
+		},
+		{
+			Mode:     HTMLMode,
+			Template: `{{ raw "<i>ok</i>" }} {{ "<i>" | safe }}`,
+			Output:   "<i>ok</i> <i>",
+		},
+		{
+			Mode:     HTMLMode,
+			Template: `{{ raw (printf "<a href=\"%s\">link</a>" (escape "x&y")) }}`,
+			Output:   `<a href="x&amp;y">link</a>`,
+		},
+		{
+			Mode:     MarkdownMode,
+			Template: `{{ range $i, $item := .responce.items }}{{ $x := $item }}{{ if $i }}, {{ end }}{{ $x }}{{ end }}`,
+			Output:   `x\_1, y\_2`,
+		},
+		{
+			Mode:     MarkdownMode,
+			Template: `{{ define "item" }}_{{ . }}_{{ end }}{{ with .responce.items }}{{ template "item" index . 0 }}{{ end }}`,
+			Output:   `_x\_1_`,
+		},
+	}
+	for _, testCase := range testCases {
+		processor := HandProcessorImp{
+			URLRecord: &URLRecord{URLName: "test"},
+			parseMode: testCase.Mode,
+		}
+		buf := new(bytes.Buffer)
+		err := processor.render(buf, testCase.Template, data)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Template, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s %s: expected %q got %q", testCase.Mode, testCase.Template, testCase.Output, buf.String())
+		}
+	}
+}
+
+func TestJSONBodyEscape(t *testing.T) {
+	// проверяем, что значения в json строках экранируются как содержимое строки,
+	// а значения вне строк вставляются как json и не добавляют полей
+	data := map[string]interface{}{
+		"name":  `a", "admin": true, "b`,
+		"count": 5,
+		"tags":  []interface{}{"x"},
+	}
+	testCases := []struct {
+		Template string
+		Output   string
+		Err      bool
+	}{
+		{
+			Template: `{"name": "{{ .name }}", "count": {{ .count }}}`,
+			Output:   `{"name": "a\", \"admin\": true, \"b", "count": 5}`,
+		},
+		{
+			Template: `{"name": {{ printf "%q" "a b" }}, "tags": {{ toJson .tags }}}`,
+			Output:   `{"name": "a b", "tags": ["x"]}`,
+		},
+		{
+			Template: `{"name": {{ .name }}}`,
+			Output:   `{"name": "a\", \"admin\": true, \"b"}`,
+		},
+		{
+			Template: `{"name": "{{ if .count }}\"{{ .name }}{{ end }}", "tags": [{{ range $i, $tag := .tags }}{{ if $i }}, {{ end }}"{{ $tag }}"{{ end }}]}`,
+			Output:   `{"name": "\"a\", \"admin\": true, \"b", "tags": ["x"]}`,
+		},
+		{
+			Template: `{"name": "{{ .name }}", "count": }`,
+			Err:      true,
+		},
+		{
+			Template: `{"name": {{ if .count }}"{{ end }}}`,
+			Err:      true,
+		},
+	}
+	for _, testCase := range testCases {
+		body, err := renderBodyTemplate("test", testCase.Template, JSONContent, data)
+		if testCase.Err {
+			if err == nil {
+				t.Errorf("%s: expected error got body %s", testCase.Template, body)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Template, err.Error())
+			continue
+		}
+		if body != testCase.Output {
+			t.Errorf("%s: expected %q got %q", testCase.Template, testCase.Output, body)
+		}
+	}
+}
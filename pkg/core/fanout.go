@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultFanOutTarget name of the target in templates if it isn't specified
+	defaultFanOutTarget = "target"
+	// defaultFanOutSeparator separator of targets list in param value
+	defaultFanOutSeparator = ","
+)
+
+// FanOutInfo description of the request executed concurrently for each
+// target, targets are got from static list or from the value of hand param
+type FanOutInfo struct {
+	Targets     []string `json:"targets" yaml:"targets"`
+	Param       string   `json:"param" yaml:"param"`
+	Separator   string   `json:"separator" yaml:"separator"`
+	As          string   `json:"as" yaml:"as"`
+	Concurrency int      `json:"concurrency" yaml:"concurrency"`
+}
+
+// GetAs get name of the target in templates
+func (fanOut *FanOutInfo) GetAs() string {
+	if fanOut.As == "" {
+		return defaultFanOutTarget
+	}
+	return fanOut.As
+}
+
+// GetSeparator get separator of targets in param value
+func (fanOut *FanOutInfo) GetSeparator() string {
+	if fanOut.Separator == "" {
+		return defaultFanOutSeparator
+	}
+	return fanOut.Separator
+}
+
+// getTargets get targets list
+func (fanOut *FanOutInfo) getTargets(params map[string]interface{}) ([]string, error) {
+	if fanOut.Param == "" {
+		return fanOut.Targets, nil
+	}
+	value, ok := params[fanOut.Param]
+	if !ok {
+		return nil, fmt.Errorf("No value for fan-out param %s", fanOut.Param)
+	}
+	targets := make([]string, 0)
+	// values of list params and lists got from steps are used as is
+	list := reflect.ValueOf(value)
+	if value != nil && (list.Kind() == reflect.Slice || list.Kind() == reflect.Array) {
+		for i := 0; i < list.Len(); i++ {
+			targets = append(targets, toString(list.Index(i).Interface()))
+		}
+		return targets, nil
+	}
+	for _, target := range strings.Split(fmt.Sprintf("%v", value), fanOut.GetSeparator()) {
+		target = strings.TrimSpace(target)
+		if target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// fanOutResult result of the request to one target
+type fanOutResult struct {
+	target     string
+	responce   *handResponce
+	displayURL string
+	cached     bool
+	err        error
+}
+
+func (result *fanOutResult) templateData() map[string]interface{} {
+	data := map[string]interface{}{
+		"target": result.target,
+		"url":    result.displayURL,
+		"cached": result.cached,
+		"error":  nil,
+	}
+	if result.err != nil {
+		data["error"] = result.err.Error()
+	}
+	if result.responce != nil {
+		data["responce"] = result.responce.Data
+		data["status"] = result.responce.Status
+		data["headers"] = flattenHeaders(result.responce.Header)
+	}
+	return data
+}
+
+// requestTarget execute hand request for one target
+func (processor *HandProcessorImp) requestTarget(ctx context.Context, params map[string]interface{}, target string, logger *log.Entry) *fanOutResult {
+	targetParams := make(map[string]interface{}, len(params)+1)
+	for name, value := range params {
+		// each request gets only it's own target instead of the whole list
+		if name == processor.FanOut.Param {
+			continue
+		}
+		targetParams[name] = value
+	}
+	targetParams[processor.FanOut.GetAs()] = target
+
+	result := fanOutResult{target: target}
+	result.responce, result.displayURL, result.cached, result.err = processor.fetch(ctx, targetParams, logger)
+	if result.err != nil {
+		logger.Warnf("Request failed %s", result.err.Error())
+		return &result
+	}
+	if isErrorStatus(result.responce.Status) {
+		result.err = fmt.Errorf("Request failed with status %d", result.responce.Status)
+	}
+	return &result
+}
+
+// processFanOut execute request for all targets concurrently and render
+// results with body template, failed targets don't abort others
+func (processor *HandProcessorImp) processFanOut(ctx context.Context, writer io.Writer, params map[string]interface{}, logger *log.Entry) error {
+	targets, err := processor.FanOut.getTargets(params)
+	if err != nil {
+		return err
+	}
+	concurrency := processor.FanOut.Concurrency
+	if concurrency <= 0 || concurrency > len(targets) {
+		concurrency = len(targets)
+	}
+
+	results := make([]*fanOutResult, len(targets))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = processor.requestTarget(ctx, params, target, logger.WithField("target", target))
+		}(i, target)
+	}
+	wg.Wait()
+
+	resultsData := make([]interface{}, 0, len(results))
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+		resultsData = append(resultsData, result.templateData())
+	}
+
+	meta := map[string]interface{}{
+		"params": params,
+		"failed": failed,
+	}
+	addUser(ctx, meta)
+	templateData := map[string]interface{}{
+		"results": resultsData,
+		"meta":    meta,
+	}
+	return processor.render(writer, processor.Body, templateData)
+}
+
+func validateFanOut(urlRecord *URLRecord) []error {
+	errs := make([]error, 0)
+	fanOut := urlRecord.FanOut
+	if (len(fanOut.Targets) == 0) == (fanOut.Param == "") {
+		errs = append(errs, errors.New("fan-out requires either targets or param"))
+	}
+	if fanOut.Param != "" {
+		if _, ok := urlRecord.Parameters[fanOut.Param]; !ok {
+			errs = append(errs, fmt.Errorf("fan-out param %s is not described", fanOut.Param))
+		}
+	}
+	if _, ok := urlRecord.Parameters[fanOut.GetAs()]; ok {
+		errs = append(errs, fmt.Errorf("fan-out target name %s conflicts with param", fanOut.GetAs()))
+	}
+	if fanOut.Concurrency < 0 {
+		errs = append(errs, errors.New("fan-out concurrency can't be negative"))
+	}
+	if len(urlRecord.Steps) != 0 {
+		errs = append(errs, errors.New("fan-out can't be combined with steps"))
+	}
+	if urlRecord.URLTemplate == "" {
+		errs = append(errs, errors.New("fan-out requires url_template"))
+	}
+	return errs
+}
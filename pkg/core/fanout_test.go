@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestFanOut(t *testing.T) {
+	// проверяем параллельные запросы ко всем целям и сбор ошибок
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		region := req.URL.Query().Get("region")
+		if region == "broken" {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{
+			"region": region,
+			"id":     req.URL.Query().Get("id"),
+			"list":   req.URL.Query()["regions"],
+		})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	body := `{{ range .results }}{{ .target }}: {{ if .error }}{{ .error }}{{ else }}{{ .responce.region }} {{ .responce.id }}{{ with .responce.list }} {{ . }}{{ end }}{{ end }}
+{{ end }}failed {{ .meta.failed }}`
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"static": {
+			URLTemplate: serv.URL + "/entity?region={{ .region }}",
+			Parameters: ParamsDescription{
+				"id": ParamInfo{
+					Name:        "id",
+					Type:        StringType,
+					Destination: QueryPlaced,
+				},
+			},
+			FanOut: &FanOutInfo{
+				Targets:     []string{"eu", "broken", "us"},
+				As:          "region",
+				Concurrency: 2,
+			},
+			Body:    body,
+			URLName: "static",
+		},
+		"from_param": {
+			URLTemplate: serv.URL + "/entity?region={{ .target }}",
+			Parameters: ParamsDescription{
+				"id": ParamInfo{
+					Name:        "id",
+					Type:        StringType,
+					Destination: QueryPlaced,
+				},
+				"regions": ParamInfo{
+					Name:        "regions",
+					Type:        StringType,
+					Destination: URLPlaced,
+				},
+			},
+			FanOut: &FanOutInfo{
+				Param: "regions",
+			},
+			Body:    body,
+			URLName: "from_param",
+		},
+		"from_list": {
+			URLTemplate: serv.URL + "/entity?region={{ .target }}",
+			Parameters: ParamsDescription{
+				"regions": ParamInfo{
+					Name:        "regions",
+					Type:        ListType,
+					Separator:   ";",
+					Destination: URLPlaced,
+				},
+			},
+			FanOut: &FanOutInfo{
+				Param: "regions",
+			},
+			Body:    body,
+			URLName: "from_list",
+		},
+		"from_query": {
+			URLTemplate: serv.URL + "/entity?region={{ .target }}",
+			Parameters: ParamsDescription{
+				"regions": ParamInfo{
+					Name:        "regions",
+					Type:        ListType,
+					Destination: QueryPlaced,
+				},
+			},
+			FanOut: &FanOutInfo{
+				Param: "regions",
+			},
+			Body:    body,
+			URLName: "from_query",
+		},
+	}), serv.Client())
+
+	testCases := []struct {
+		Hand   string
+		Params map[string]interface{}
+		Output string
+	}{
+		{
+			Hand:   "static",
+			Params: map[string]interface{}{"id": "1"},
+			Output: "eu: eu 1\nbroken: Request failed with status 500\nus: us 1\nfailed 1",
+		},
+		{
+			Hand:   "from_param",
+			Params: map[string]interface{}{"id": "2", "regions": "asia, eu"},
+			Output: "asia: asia 2\neu: eu 2\nfailed 0",
+		},
+		{
+			// список разбирается по разделителю параметра, а не fan-out
+			Hand:   "from_list",
+			Params: map[string]interface{}{"regions": "asia;eu,us"},
+			Output: "asia: asia \neu,us: eu,us \nfailed 0",
+		},
+		{
+			// список целей из параметра запроса не отправляется с каждой целью
+			Hand:   "from_query",
+			Params: map[string]interface{}{"regions": "asia,eu"},
+			Output: "asia: asia \neu: eu \nfailed 0",
+		},
+	}
+	for _, testCase := range testCases {
+		hand, err := processor.GetHand(testCase.Hand)
+		if err != nil {
+			t.Fatalf("%s: failed to get hand %s", testCase.Hand, err.Error())
+		}
+		params := make(map[string]interface{}, len(testCase.Params))
+		for name, value := range testCase.Params {
+			param, err := hand.GetParam(name)
+			if err != nil {
+				t.Fatalf("%s: failed to get param %s", testCase.Hand, err.Error())
+			}
+			params[name], err = param.ParseFromString(value.(string))
+			if err != nil {
+				t.Fatalf("%s: failed to parse param %s", testCase.Hand, err.Error())
+			}
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, params, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Hand, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output\n%s\ngot\n%s", testCase.Hand, testCase.Output, buf.String())
+		}
+	}
+}
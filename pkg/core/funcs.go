@@ -0,0 +1,380 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// timeLayouts named layouts accepted by date functions
+var timeLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+	"time":     "15:04",
+}
+
+// ErrDivisionByZero division by zero in template
+var ErrDivisionByZero = errors.New("division by zero")
+
+// templateFuncs functions available in all hand templates, value
+// is the last argument of each function to be used in pipelines
+// like {{ .responce.name | trim | truncate 10 }}
+func templateFuncs(data interface{}) template.FuncMap {
+	return template.FuncMap{
+		// GetValue get value by dotted path from responce,
+		// or from template data if there is no responce
+		"GetValue": func(path string) interface{} {
+			source := data
+			if fields, ok := data.(map[string]interface{}); ok {
+				if responce, ok := fields["responce"]; ok {
+					source = responce
+				}
+			}
+			value, _ := lookupPath(source, path)
+			return value
+		},
+		"lookup": func(path string, value interface{}) interface{} {
+			result, _ := lookupPath(value, path)
+			return result
+		},
+
+		"now": time.Now,
+		"utc": func(value interface{}) (time.Time, error) {
+			parsed, err := toTime(value)
+			return parsed.UTC(), err
+		},
+		"parseTime":  parseTime,
+		"formatTime": formatTime,
+		"addTime":    addTime,
+
+		"formatNumber": formatNumber,
+		"round":        round,
+
+		"upper":     func(value interface{}) string { return strings.ToUpper(toString(value)) },
+		"lower":     func(value interface{}) string { return strings.ToLower(toString(value)) },
+		"title":     title,
+		"trim":      func(value interface{}) string { return strings.TrimSpace(toString(value)) },
+		"truncate":  truncate,
+		"join":      join,
+		"split":     func(separator string, value interface{}) []string { return strings.Split(toString(value), separator) },
+		"replace":   replace,
+		"contains":  func(substr string, value interface{}) bool { return strings.Contains(toString(value), substr) },
+		"hasPrefix": func(prefix string, value interface{}) bool { return strings.HasPrefix(toString(value), prefix) },
+		"hasSuffix": func(suffix string, value interface{}) bool { return strings.HasSuffix(toString(value), suffix) },
+
+		"default":  defaultValue,
+		"coalesce": coalesce,
+
+		"add": mathFunc(add),
+		"sub": mathFunc(sub),
+		"mul": mathFunc(mul),
+		"div": mathFunc(div),
+		"mod": mathFunc(mod),
+		"max": mathFunc(func(a, b float64) (float64, error) { return math.Max(a, b), nil }),
+		"min": mathFunc(func(a, b float64) (float64, error) { return math.Min(a, b), nil }),
+
+		// pipeline forms take value last: {{ .total | divBy 2 }} is total / 2
+		"subBy": pipedMathFunc(sub),
+		"divBy": pipedMathFunc(div),
+		"modBy": pipedMathFunc(mod),
+
+		// secret calls are replaced with values on loading descriptions
+		"secret": func(name string) (string, error) {
+			return "", fmt.Errorf("secret %s can be used only in url_template, headers and default values", name)
+		},
+
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+
+		// escape is replaced with parse mode escaper in body templates
+		escapeFuncName: escaper(PlainMode),
+		"raw":          raw,
+		"safe":         raw,
+	}
+}
+
+// toString format any value as a string
+func toString(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case []byte:
+		return string(typed)
+	case fmt.Stringer:
+		return typed.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// toFloat convert number or numeric string to float
+func toFloat(value interface{}) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case float32:
+		return float64(typed), nil
+	case int:
+		return float64(typed), nil
+	case int64:
+		return float64(typed), nil
+	case int32:
+		return float64(typed), nil
+	case json.Number:
+		return typed.Float64()
+	case string:
+		result, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", typed)
+		}
+		return result, nil
+	}
+	return 0, fmt.Errorf("%v of type %T is not a number", value, value)
+}
+
+// toTime convert time, unix timestamp in seconds or RFC3339 string to time
+func toTime(value interface{}) (time.Time, error) {
+	switch typed := value.(type) {
+	case time.Time:
+		return typed, nil
+	case TimeValue:
+		return typed.Time, nil
+	}
+	if str, ok := value.(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, str); err == nil {
+			return parsed, nil
+		}
+	}
+	timestamp, err := toFloat(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v is neither time nor timestamp", value)
+	}
+	seconds, fraction := math.Modf(timestamp)
+	return time.Unix(int64(seconds), int64(fraction*float64(time.Second))), nil
+}
+
+// getLayout get go time layout by name
+func getLayout(layout string) string {
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// parseTime parse string with layout into time
+func parseTime(layout string, value interface{}) (time.Time, error) {
+	return time.Parse(getLayout(layout), toString(value))
+}
+
+// formatTime format time, timestamp or RFC3339 string with layout
+func formatTime(layout string, value interface{}) (string, error) {
+	parsed, err := toTime(value)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Format(getLayout(layout)), nil
+}
+
+// addTime add duration like "1h30m" or "-24h" to time
+func addTime(duration string, value interface{}) (time.Time, error) {
+	parsed, err := toTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	delta, err := time.ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.Add(delta), nil
+}
+
+// formatNumber format number with fixed decimals and thousands separated by space
+func formatNumber(decimals int, value interface{}) (string, error) {
+	number, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+	formatted := strconv.FormatFloat(math.Abs(number), 'f', decimals, 64)
+	integer := formatted
+	fraction := ""
+	if point := strings.IndexByte(formatted, '.'); point >= 0 {
+		integer, fraction = formatted[:point], formatted[point:]
+	}
+	var builder strings.Builder
+	if number < 0 {
+		builder.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i != 0 && (len(integer)-i)%3 == 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteRune(digit)
+	}
+	builder.WriteString(fraction)
+	return builder.String(), nil
+}
+
+// round round number to precision decimals
+func round(precision int, value interface{}) (float64, error) {
+	number, err := toFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	scale := math.Pow(10, float64(precision))
+	return math.Round(number*scale) / scale, nil
+}
+
+// title upper first letter of each word, words are split by spaces
+// and punctuation except apostrophes so "don't" stays one word
+func title(value interface{}) string {
+	runes := []rune(toString(value))
+	inWord := false
+	for i, char := range runes {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.IsMark(char):
+			if !inWord {
+				runes[i] = unicode.ToTitle(char)
+			}
+			inWord = true
+		case inWord && (char == '\'' || char == '’'):
+		default:
+			inWord = false
+		}
+	}
+	return string(runes)
+}
+
+// truncate cut string to length runes adding ellipsis
+func truncate(length int, value interface{}) string {
+	runes := []rune(toString(value))
+	if length < 0 || len(runes) <= length {
+		return string(runes)
+	}
+	if length == 0 {
+		return ""
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// replace replace all occurrences of old substring
+func replace(old string, new string, value interface{}) string {
+	return strings.ReplaceAll(toString(value), old, new)
+}
+
+// join join list of any values with separator
+func join(separator string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return "", fmt.Errorf("%v of type %T is not a list", value, value)
+	}
+	parts := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		parts = append(parts, toString(list.Index(i).Interface()))
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// isEmpty check if value is nil, zero or empty collection
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return reflected.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return reflected.IsNil()
+	}
+	return reflected.IsZero()
+}
+
+// defaultValue get value or fallback if value is empty
+func defaultValue(fallback interface{}, value interface{}) interface{} {
+	if isEmpty(value) {
+		return fallback
+	}
+	return value
+}
+
+// coalesce get first non empty value
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+func add(a, b float64) (float64, error) { return a + b, nil }
+func sub(a, b float64) (float64, error) { return a - b, nil }
+func mul(a, b float64) (float64, error) { return a * b, nil }
+
+func div(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return a / b, nil
+}
+
+func mod(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return math.Mod(a, b), nil
+}
+
+// mathFunc build template function of two numbers of any numeric type,
+// first argument is the left operand so {{ div .total 2 }} is total / 2
+func mathFunc(operation func(a, b float64) (float64, error)) func(a, b interface{}) (float64, error) {
+	return func(a, b interface{}) (float64, error) {
+		first, err := toFloat(a)
+		if err != nil {
+			return 0, err
+		}
+		second, err := toFloat(b)
+		if err != nil {
+			return 0, err
+		}
+		return operation(first, second)
+	}
+}
+
+// pipedMathFunc build template function for pipelines, value is passed
+// last but it's the left operand so {{ .total | divBy 2 }} is total / 2
+func pipedMathFunc(operation func(a, b float64) (float64, error)) func(arg, value interface{}) (float64, error) {
+	calc := mathFunc(operation)
+	return func(arg, value interface{}) (float64, error) {
+		return calc(value, arg)
+	}
+}
+
+func toJSON(value interface{}) (string, error) {
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func toPrettyJSON(value interface{}) (string, error) {
+	result, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
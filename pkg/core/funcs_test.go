@@ -0,0 +1,66 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	// проверяем функции шаблонов на данных, похожих на ответ сервера
+	data := map[string]interface{}{
+		"responce": map[string]interface{}{
+			"user": map[string]interface{}{
+				"name":  "  Some Long User Name ",
+				"tags":  []interface{}{"a", "b", float64(3)},
+				"empty": "",
+			},
+			"created": float64(1600000000),
+			"date":    "2020-09-13T12:26:40Z",
+			"amount":  float64(1234567.891),
+			"count":   float64(7),
+		},
+	}
+	testCases := []struct {
+		Template string
+		Output   string
+		HasError bool
+	}{
+		{Template: `{{ GetValue "user.tags.1" }}`, Output: "b"},
+		{Template: `{{ GetValue "user.missing" | default "none" }}`, Output: "none"},
+		{Template: `{{ lookup "user.tags[2]" .responce }}`, Output: "3"},
+		{Template: `{{ .responce.created | utc | formatTime "datetime" }}`, Output: "2020-09-13 12:26:40"},
+		{Template: `{{ .responce.date | formatTime "date" }}`, Output: "2020-09-13"},
+		{Template: `{{ parseTime "date" "2020-01-02" | addTime "48h" | formatTime "date" }}`, Output: "2020-01-04"},
+		{Template: `{{ .responce.amount | formatNumber 2 }}`, Output: "1 234 567.89"},
+		{Template: `{{ formatNumber 0 -1000 }}`, Output: "-1 000"},
+		{Template: `{{ round 2 3.14159 }}`, Output: "3.14"},
+		{Template: `{{ .responce.user.name | trim | upper }}`, Output: "SOME LONG USER NAME"},
+		{Template: `{{ .responce.user.name | trim | truncate 9 }}`, Output: "Some Lon…"},
+		{Template: `{{ .responce.user.tags | join ", " }}`, Output: "a, b, 3"},
+		{Template: `{{ range split "," "x,y" }}[{{ . }}]{{ end }}`, Output: "[x][y]"},
+		{Template: `{{ replace "Long " "" .responce.user.name }}`, Output: "  Some User Name "},
+		{Template: `{{ coalesce .responce.user.empty .responce.user.missing "first" }}`, Output: "first"},
+		{Template: `{{ add .responce.count 3 }} {{ sub .responce.count 2 }} {{ mul .responce.count 2 }} {{ div .responce.count 2 }} {{ mod .responce.count 4 }}`, Output: "10 5 14 3.5 3"},
+		{Template: `{{ sub 2 .responce.count }} {{ div 14 .responce.count }} {{ mod 9 .responce.count }}`, Output: "-5 2 2"},
+		{Template: `{{ .responce.count | sub 2 }} {{ .responce.count | div 14 }} {{ .responce.count | mod 9 }}`, Output: "-5 2 2"},
+		{Template: `{{ .responce.count | subBy 2 }} {{ .responce.count | divBy 2 }} {{ .responce.count | modBy 4 }}`, Output: "5 3.5 3"},
+		{Template: `{{ subBy 2 .responce.count }} {{ divBy 2 .responce.count }} {{ modBy 4 .responce.count }}`, Output: "5 3.5 3"},
+		{Template: `{{ .responce.count | subBy 2 | divBy 10 | modBy 0.3 }}`, Output: "0.2"},
+		{Template: `{{ max 1 .responce.count }} {{ min "2" .responce.count }}`, Output: "7 2"},
+		{Template: `{{ "don't stop—o'neil élan-vital x_y" | title }}`, Output: "Don't Stop—O'neil Élan-Vital X_Y"},
+		{Template: `{{ div 1 0 }}`, HasError: true},
+		{Template: `{{ 1 | divBy 0 }}`, HasError: true},
+		{Template: `{{ add "a" 1 }}`, HasError: true},
+		{Template: `{{ .responce.user.tags | toJson }}`, Output: `["a","b",3]`},
+		{Template: `{{ .responce.user.tags | toPrettyJson }}`, Output: "[\n  \"a\",\n  \"b\",\n  3\n]"},
+	}
+	for _, testCase := range testCases {
+		output, err := renderTemplate("test", testCase.Template, data)
+		if (err != nil) != testCase.HasError {
+			t.Errorf("%s: unexpected error state %v", testCase.Template, err)
+			continue
+		}
+		if output != testCase.Output {
+			t.Errorf("%s: expected %q got %q", testCase.Template, testCase.Output, output)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultImportedBody responce template of imported hands
+const DefaultImportedBody = "{{ toPrettyJson .responce }}"
+
+var (
+	// templateIdentifier name which can be used as {{ .name }} in templates
+	templateIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// handNameSymbols symbols allowed in generated hand names
+	handNameSymbols = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+//ImportOptions options of hands generation from other formats, BaseURL
+// overrides server of OpenAPI document or scheme and host of imported
+// requests, Body is a responce template of generated hands,
+// DefaultImportedBody is used if it's empty
+type ImportOptions struct {
+	BaseURL string
+	Body    string
+}
+
+// body responce template of generated hands
+func (options *ImportOptions) body() string {
+	if options.Body == "" {
+		return DefaultImportedBody
+	}
+	return options.Body
+}
+
+// templateReference reference to param value in template
+func templateReference(name string) string {
+	if templateIdentifier.MatchString(name) {
+		return "." + name
+	}
+	return fmt.Sprintf("index . %q", name)
+}
+
+// sanitizeHandName replace symbols which can't be used in hand name
+func sanitizeHandName(name string) string {
+	return strings.Trim(handNameSymbols.ReplaceAllString(name, "_"), "_")
+}
+
+// pathHandName name of the hand built from method and url path
+func pathHandName(method string, path string) string {
+	return sanitizeHandName(strings.ToLower(method) + "_" + strings.Trim(path, "/"))
+}
+
+// addUnique add hand to container, number is added to hand
+// name if it's taken, generated names can clash
+func (container URLContrainer) addUnique(hand URLRecord) {
+	if _, ok := container[hand.URLName]; ok {
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s_%d", hand.URLName, i)
+			if _, ok := container[candidate]; !ok {
+				hand.URLName = candidate
+				break
+			}
+		}
+	}
+	container[hand.URLName] = hand
+}
+
+//WriteDescriptionsYAML write hands description in format of descriptions file
+func WriteDescriptionsYAML(writer io.Writer, container URLContrainer) error {
+	data, err := yaml.Marshal(container)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
@@ -0,0 +1,650 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrNotOpenAPIDocument document has neither openapi nor swagger version
+	ErrNotOpenAPIDocument = errors.New("document is not an OpenAPI 3 or Swagger 2 document")
+
+	// openAPIMethods methods of path item operations converted to hands
+	openAPIMethods = []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodHead,
+	}
+	// pathParameter parameter placeholder in OpenAPI path
+	pathParameter = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// openAPIType schema type, OpenAPI 3.1 allows list of types like [string, null]
+type openAPIType string
+
+func (tp *openAPIType) set(types []string) {
+	for _, name := range types {
+		if name != "null" {
+			*tp = openAPIType(name)
+			return
+		}
+	}
+}
+
+func (tp *openAPIType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*tp = openAPIType(name)
+		return nil
+	}
+	var types []string
+	err := unmarshal(&types)
+	if err != nil {
+		return err
+	}
+	tp.set(types)
+	return nil
+}
+
+func (tp *openAPIType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*tp = openAPIType(name)
+		return nil
+	}
+	var types []string
+	err := json.Unmarshal(data, &types)
+	if err != nil {
+		return err
+	}
+	tp.set(types)
+	return nil
+}
+
+// openAPISchemaFields fields of schema, Swagger 2 places them into parameter itself
+type openAPISchemaFields struct {
+	Type             openAPIType    `json:"type" yaml:"type"`
+	Format           string         `json:"format" yaml:"format"`
+	Enum             []interface{}  `json:"enum" yaml:"enum"`
+	Items            *openAPISchema `json:"items" yaml:"items"`
+	Default          interface{}    `json:"default" yaml:"default"`
+	Minimum          *float64       `json:"minimum" yaml:"minimum"`
+	Maximum          *float64       `json:"maximum" yaml:"maximum"`
+	MinLength        *int           `json:"minLength" yaml:"minLength"`
+	MaxLength        *int           `json:"maxLength" yaml:"maxLength"`
+	Pattern          string         `json:"pattern" yaml:"pattern"`
+	CollectionFormat string         `json:"collectionFormat" yaml:"collectionFormat"`
+}
+
+type openAPISchema struct {
+	Ref                 string `json:"$ref" yaml:"$ref"`
+	openAPISchemaFields `yaml:",inline"`
+	Description         string                    `json:"description" yaml:"description"`
+	Properties          map[string]*openAPISchema `json:"properties" yaml:"properties"`
+	Required            []string                  `json:"required" yaml:"required"`
+}
+
+type openAPIParameter struct {
+	Ref                 string `json:"$ref" yaml:"$ref"`
+	openAPISchemaFields `yaml:",inline"`
+	Name                string         `json:"name" yaml:"name"`
+	In                  string         `json:"in" yaml:"in"`
+	Description         string         `json:"description" yaml:"description"`
+	Required            bool           `json:"required" yaml:"required"`
+	Schema              *openAPISchema `json:"schema" yaml:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema" yaml:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref         string                      `json:"$ref" yaml:"$ref"`
+	Description string                      `json:"description" yaml:"description"`
+	Required    bool                        `json:"required" yaml:"required"`
+	Content     map[string]openAPIMediaType `json:"content" yaml:"content"`
+}
+
+type openAPIOperation struct {
+	OperationID string              `json:"operationId" yaml:"operationId"`
+	Summary     string              `json:"summary" yaml:"summary"`
+	Description string              `json:"description" yaml:"description"`
+	Parameters  []openAPIParameter  `json:"parameters" yaml:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody" yaml:"requestBody"`
+	Consumes    []string            `json:"consumes" yaml:"consumes"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `json:"parameters" yaml:"parameters"`
+	Get        *openAPIOperation  `json:"get" yaml:"get"`
+	Post       *openAPIOperation  `json:"post" yaml:"post"`
+	Put        *openAPIOperation  `json:"put" yaml:"put"`
+	Patch      *openAPIOperation  `json:"patch" yaml:"patch"`
+	Delete     *openAPIOperation  `json:"delete" yaml:"delete"`
+	Head       *openAPIOperation  `json:"head" yaml:"head"`
+}
+
+// operation get operation of path item by method
+func (item *openAPIPathItem) operation(method string) *openAPIOperation {
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPost:
+		return item.Post
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPatch:
+		return item.Patch
+	case http.MethodDelete:
+		return item.Delete
+	case http.MethodHead:
+		return item.Head
+	}
+	return nil
+}
+
+type openAPIServer struct {
+	URL       string `json:"url" yaml:"url"`
+	Variables map[string]struct {
+		Default string `json:"default" yaml:"default"`
+	} `json:"variables" yaml:"variables"`
+}
+
+type openAPIDocument struct {
+	OpenAPI     string                      `json:"openapi" yaml:"openapi"`
+	Swagger     string                      `json:"swagger" yaml:"swagger"`
+	Servers     []openAPIServer             `json:"servers" yaml:"servers"`
+	Host        string                      `json:"host" yaml:"host"`
+	BasePath    string                      `json:"basePath" yaml:"basePath"`
+	Schemes     []string                    `json:"schemes" yaml:"schemes"`
+	Consumes    []string                    `json:"consumes" yaml:"consumes"`
+	Paths       map[string]openAPIPathItem  `json:"paths" yaml:"paths"`
+	Parameters  map[string]openAPIParameter `json:"parameters" yaml:"parameters"`
+	Definitions map[string]*openAPISchema   `json:"definitions" yaml:"definitions"`
+	Components  struct {
+		Parameters    map[string]openAPIParameter   `json:"parameters" yaml:"parameters"`
+		Schemas       map[string]*openAPISchema     `json:"schemas" yaml:"schemas"`
+		RequestBodies map[string]openAPIRequestBody `json:"requestBodies" yaml:"requestBodies"`
+	} `json:"components" yaml:"components"`
+}
+
+// openAPIImporter converts operations of OpenAPI document into hands
+type openAPIImporter struct {
+	doc     *openAPIDocument
+	options ImportOptions
+	logger  *log.Entry
+}
+
+// decodeOpenAPIDocument decode json or yaml OpenAPI document
+func decodeOpenAPIDocument(data []byte) (*openAPIDocument, error) {
+	doc := &openAPIDocument{}
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, doc)
+	} else {
+		err = yaml.Unmarshal(data, doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse OpenAPI document %w", err)
+	}
+	if doc.OpenAPI == "" && doc.Swagger == "" {
+		return nil, ErrNotOpenAPIDocument
+	}
+	return doc, nil
+}
+
+// refName name of component referenced by local reference with prefix
+func refName(ref string, prefix string) (string, bool) {
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, prefix), true
+}
+
+// resolveSchema follow reference of schema, only local references are supported
+func (importer *openAPIImporter) resolveSchema(schema *openAPISchema) (*openAPISchema, error) {
+	visited := make(map[string]bool)
+	for schema != nil && schema.Ref != "" {
+		if visited[schema.Ref] {
+			return nil, fmt.Errorf("reference cycle on %s", schema.Ref)
+		}
+		visited[schema.Ref] = true
+		var resolved *openAPISchema
+		if name, ok := refName(schema.Ref, "#/components/schemas/"); ok {
+			resolved = importer.doc.Components.Schemas[name]
+		} else if name, ok := refName(schema.Ref, "#/definitions/"); ok {
+			resolved = importer.doc.Definitions[name]
+		}
+		if resolved == nil {
+			return nil, fmt.Errorf("unresolved reference %s", schema.Ref)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+// resolveParameter follow reference of parameter, only local references are supported
+func (importer *openAPIImporter) resolveParameter(param openAPIParameter) (openAPIParameter, error) {
+	if param.Ref == "" {
+		return param, nil
+	}
+	if name, ok := refName(param.Ref, "#/components/parameters/"); ok {
+		if resolved, ok := importer.doc.Components.Parameters[name]; ok {
+			return resolved, nil
+		}
+	} else if name, ok := refName(param.Ref, "#/parameters/"); ok {
+		if resolved, ok := importer.doc.Parameters[name]; ok {
+			return resolved, nil
+		}
+	}
+	return param, fmt.Errorf("unresolved reference %s", param.Ref)
+}
+
+// baseURL url the paths of document are relative to
+func (importer *openAPIImporter) baseURL() (string, error) {
+	if importer.options.BaseURL != "" {
+		return strings.TrimSuffix(importer.options.BaseURL, "/"), nil
+	}
+	doc := importer.doc
+	var base string
+	switch {
+	case len(doc.Servers) != 0:
+		server := doc.Servers[0]
+		base = pathParameter.ReplaceAllStringFunc(server.URL, func(variable string) string {
+			if value, ok := server.Variables[strings.Trim(variable, "{}")]; ok {
+				return value.Default
+			}
+			return variable
+		})
+	case doc.Host != "":
+		scheme := "https"
+		if len(doc.Schemes) != 0 {
+			scheme = doc.Schemes[0]
+		}
+		base = scheme + "://" + doc.Host + doc.BasePath
+	}
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		return "", fmt.Errorf("document has no absolute server url %q, base url should be specified", base)
+	}
+	return strings.TrimSuffix(base, "/"), nil
+}
+
+// urlTemplate convert OpenAPI path into url template
+func urlTemplate(base string, path string) string {
+	return base + pathParameter.ReplaceAllStringFunc(path, func(placeholder string) string {
+		return "{{ " + templateReference(strings.Trim(placeholder, "{}")) + " }}"
+	})
+}
+
+// operationName name of the hand, operationId is used if it's specified
+func operationName(method string, path string, operation *openAPIOperation) string {
+	if operation.OperationID == "" {
+		return pathHandName(method, path)
+	}
+	return sanitizeHandName(operation.OperationID)
+}
+
+// paramType get type of param from schema, ok is false if
+// schema can't be represented as a param
+func paramType(schema *openAPISchema, info *ParamInfo) bool {
+	switch schema.Type {
+	case "integer":
+		info.Type = IntegerType
+	case "number":
+		info.Type = FloatType
+	case "boolean":
+		info.Type = BoolType
+	case "string", "":
+		info.Type = StringType
+		switch schema.Format {
+		case "date":
+			info.Type = DateType
+		case "date-time":
+			info.Type = DateTimeType
+			info.Layout = "RFC3339"
+		case "binary":
+			return false
+		}
+		if len(schema.Enum) != 0 {
+			info.Type = EnumType
+			info.Choices = enumChoices(schema.Enum)
+		}
+	default:
+		return false
+	}
+	if len(schema.Enum) != 0 && info.Type != EnumType {
+		info.OneOf = schema.Enum
+	}
+	return true
+}
+
+func enumChoices(enum []interface{}) []string {
+	choices := make([]string, 0, len(enum))
+	for _, value := range enum {
+		if value != nil {
+			choices = append(choices, fmt.Sprintf("%v", value))
+		}
+	}
+	return choices
+}
+
+// convertParam build param description from schema, error
+// is returned if schema can't be represented as a param
+func (importer *openAPIImporter) convertParam(name string, schema *openAPISchema, fields openAPISchemaFields) (ParamInfo, error) {
+	info := ParamInfo{Name: name}
+	if schema != nil {
+		resolved, err := importer.resolveSchema(schema)
+		if err != nil {
+			return info, err
+		}
+		fields = resolved.openAPISchemaFields
+	}
+	element := &openAPISchema{openAPISchemaFields: fields}
+	if fields.Type == "array" {
+		if fields.Items == nil {
+			return info, errors.New("array without items schema")
+		}
+		items, err := importer.resolveSchema(fields.Items)
+		if err != nil {
+			return info, err
+		}
+		if fields.CollectionFormat != "" && fields.CollectionFormat != "csv" && fields.CollectionFormat != "multi" {
+			return info, fmt.Errorf("unsupported collection format %s", fields.CollectionFormat)
+		}
+		element = items
+		info.Type = ListType
+	}
+	var elementInfo ParamInfo
+	if !paramType(element, &elementInfo) {
+		return info, fmt.Errorf("unsupported type %s", strings.TrimSpace(string(element.Type)+" "+element.Format))
+	}
+	if info.Type == ListType {
+		info.ElementType = elementInfo.Type
+		info.Layout = elementInfo.Layout
+		info.Choices = elementInfo.Choices
+	} else {
+		info = elementInfo
+		info.Name = name
+	}
+	info.Min = fields.Minimum
+	info.Max = fields.Maximum
+	info.MinLength = fields.MinLength
+	info.MaxLength = fields.MaxLength
+	info.Pattern = fields.Pattern
+	info.DefaultValue = fields.Default
+	if value, ok := info.DefaultValue.(float64); ok && info.Type == IntegerType && value == float64(int(value)) {
+		// json numbers are decoded as floats
+		info.DefaultValue = int(value)
+	}
+
+	// constraints which go can't check are dropped
+	if info.Pattern != "" {
+		if _, err := compilePattern(info.Pattern); err != nil {
+			importer.logger.Warnf("Pattern %s of param %s is dropped: %s", info.Pattern, name, err.Error())
+			info.Pattern = ""
+		}
+	}
+	if info.DefaultValue != nil {
+		checked := info
+		if errs := validateParam(&checked); len(errs) != 0 {
+			importer.logger.Warnf("Default value %v of param %s is dropped: %s", info.DefaultValue, name, errs[0].Error())
+			info.DefaultValue = nil
+		}
+	}
+	return info, nil
+}
+
+// convertBody add body placed params from request body schema
+func (importer *openAPIImporter) convertBody(hand *URLRecord, schema *openAPISchema, contentType BodyContentType, required bool) error {
+	schema, err := importer.resolveSchema(schema)
+	if err != nil {
+		return err
+	}
+	if schema == nil || (schema.Type != "object" && schema.Type != "") || len(schema.Properties) == 0 {
+		return errors.New("only object request body with properties is supported")
+	}
+	requiredProperties := make(map[string]bool)
+	for _, name := range schema.Required {
+		requiredProperties[name] = required
+	}
+	for name, property := range schema.Properties {
+		if _, ok := hand.Parameters[name]; ok {
+			importer.logger.Warnf("Body property %s of hand %s is skipped: param with the same name exists", name, hand.URLName)
+			continue
+		}
+		info, err := importer.convertParam(name, property, openAPISchemaFields{})
+		if err != nil {
+			importer.logger.Warnf("Body property %s of hand %s is skipped: %s", name, hand.URLName, err.Error())
+			continue
+		}
+		if resolved, _ := importer.resolveSchema(property); resolved != nil {
+			info.Help = resolved.Description
+		}
+		info.Destination = BodyPlaced
+		info.Optional = !requiredProperties[name]
+		hand.Parameters[name] = info
+	}
+	hand.RequestBody = &RequestBody{ContentType: contentType}
+	return nil
+}
+
+// convertOperation build hand from operation
+func (importer *openAPIImporter) convertOperation(base string, path string, method string, item *openAPIPathItem, operation *openAPIOperation) URLRecord {
+	name := operationName(method, path, operation)
+	hand := URLRecord{
+		URLTemplate: urlTemplate(base, path),
+		Method:      method,
+		Parameters:  make(ParamsDescription),
+		Body:        importer.options.body(),
+		URLName:     name,
+		Help:        strings.TrimSpace(strings.Join([]string{operation.Summary, operation.Description}, "\n")),
+	}
+	if method == http.MethodGet {
+		hand.Method = ""
+	}
+
+	// operation params override path item params with the same name and place
+	params := make([]openAPIParameter, 0, len(item.Parameters)+len(operation.Parameters))
+	positions := make(map[string]int)
+	for _, param := range append(append([]openAPIParameter{}, item.Parameters...), operation.Parameters...) {
+		param, err := importer.resolveParameter(param)
+		if err != nil {
+			importer.logger.Warnf("Param of hand %s is skipped: %s", name, err.Error())
+			continue
+		}
+		key := param.In + " " + param.Name
+		if position, ok := positions[key]; ok {
+			params[position] = param
+			continue
+		}
+		positions[key] = len(params)
+		params = append(params, param)
+	}
+
+	consumes := operation.Consumes
+	if len(consumes) == 0 {
+		consumes = importer.doc.Consumes
+	}
+	for _, param := range params {
+		var destination ParamDestination
+		switch param.In {
+		case "path":
+			destination = URLPlaced
+		case "query":
+			destination = QueryPlaced
+		case "header":
+			destination = HeaderPlaced
+		case "body":
+			// Swagger 2 request body
+			if len(consumes) != 0 && !containsString(consumes, string(JSONContent)) {
+				importer.logger.Warnf("Request body of hand %s is skipped: unsupported content type %s", name, consumes[0])
+				continue
+			}
+			err := importer.convertBody(&hand, param.Schema, JSONContent, param.Required)
+			if err != nil {
+				importer.logger.Warnf("Request body of hand %s is skipped: %s", name, err.Error())
+			}
+			continue
+		case "formData":
+			destination = BodyPlaced
+		default:
+			importer.logger.Warnf("Param %s of hand %s is skipped: unsupported location %s", param.Name, name, param.In)
+			continue
+		}
+		info, err := importer.convertParam(param.Name, param.Schema, param.openAPISchemaFields)
+		if err != nil {
+			importer.logger.Warnf("Param %s of hand %s is skipped: %s", param.Name, name, err.Error())
+			continue
+		}
+		info.Help = param.Description
+		info.Destination = destination
+		info.Optional = !param.Required && destination != URLPlaced
+		hand.Parameters[param.Name] = info
+		if param.In == "formData" {
+			hand.RequestBody = &RequestBody{ContentType: FormContent}
+		}
+	}
+
+	if operation.RequestBody != nil {
+		importer.convertRequestBody(&hand, operation.RequestBody)
+	}
+	for _, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+		if _, ok := hand.Parameters[match[1]]; !ok {
+			importer.logger.Warnf("Path param %s of hand %s isn't described, string is used", match[1], name)
+			hand.Parameters[match[1]] = ParamInfo{Name: match[1], Destination: URLPlaced, Type: StringType}
+		}
+	}
+	if len(hand.Parameters) == 0 {
+		hand.Parameters = nil
+	}
+	if hand.RequestBody != nil && (method == http.MethodGet || method == http.MethodHead) {
+		importer.logger.Warnf("Request body of hand %s is skipped: body can't be sent with %s", name, method)
+		hand.RequestBody = nil
+		for paramName, param := range hand.Parameters {
+			if param.Destination == BodyPlaced {
+				delete(hand.Parameters, paramName)
+			}
+		}
+	}
+	return hand
+}
+
+// convertRequestBody add params of OpenAPI 3 request body
+func (importer *openAPIImporter) convertRequestBody(hand *URLRecord, requestBody *openAPIRequestBody) {
+	if requestBody.Ref != "" {
+		name, ok := refName(requestBody.Ref, "#/components/requestBodies/")
+		resolved, found := importer.doc.Components.RequestBodies[name]
+		if !ok || !found {
+			importer.logger.Warnf("Request body of hand %s is skipped: unresolved reference %s", hand.URLName, requestBody.Ref)
+			return
+		}
+		requestBody = &resolved
+	}
+	for _, contentType := range []BodyContentType{JSONContent, FormContent} {
+		media, ok := requestBody.Content[string(contentType)]
+		if !ok {
+			continue
+		}
+		err := importer.convertBody(hand, media.Schema, contentType, requestBody.Required)
+		if err != nil {
+			importer.logger.Warnf("Request body of hand %s is skipped: %s", hand.URLName, err.Error())
+		}
+		return
+	}
+	importer.logger.Warnf("Request body of hand %s is skipped: no json or form content", hand.URLName)
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportOpenAPI build hands from operations of OpenAPI 3 or Swagger 2
+// document in json or yaml, operationId is used as a hand name, parts
+// of document which can't be represented in hands are skipped with warning
+func ImportOpenAPI(reader io.Reader, options ImportOptions, logger *log.Entry) (URLContrainer, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeOpenAPIDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	importer := openAPIImporter{doc: doc, options: options, logger: logger}
+	base, err := importer.baseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	container := make(URLContrainer)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range openAPIMethods {
+			operation := item.operation(method)
+			if operation == nil {
+				continue
+			}
+			hand := importer.convertOperation(base, path, method, &item, operation)
+			container.addUnique(hand)
+		}
+	}
+	return container, nil
+}
+
+// isOpenAPIDocument check if document is OpenAPI or Swagger document
+// and not hands descriptions
+func isOpenAPIDocument(data []byte) bool {
+	version := struct {
+		OpenAPI string `json:"openapi" yaml:"openapi"`
+		Swagger string `json:"swagger" yaml:"swagger"`
+	}{}
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &version)
+	} else {
+		err = yaml.Unmarshal(data, &version)
+	}
+	return err == nil && (version.OpenAPI != "" || version.Swagger != "")
+}
+
+// GetDescriptionSourceFromOpenAPI get hands description from OpenAPI document, secrets are taken from environment
+func GetDescriptionSourceFromOpenAPI(reader io.Reader, options ImportOptions, logger *log.Entry) (*SimpleDescriptionsSource, error) {
+	return GetDescriptionSourceFromOpenAPIWithSecrets(reader, options, EnvSecretsProvider{}, logger)
+}
+
+// GetDescriptionSourceFromOpenAPIWithSecrets get hands description from OpenAPI document, secrets are taken from secrets
+func GetDescriptionSourceFromOpenAPIWithSecrets(reader io.Reader, options ImportOptions, secrets SecretsProvider, logger *log.Entry) (*SimpleDescriptionsSource, error) {
+	container, err := ImportOpenAPI(reader, options, logger)
+	if err != nil {
+		return nil, err
+	}
+	err = validateContainer(&container, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return NewDescriptionSourceFromDict(container), nil
+}
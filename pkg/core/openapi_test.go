@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const openAPIDocumentYAML = `
+openapi: 3.0.1
+servers:
+  - url: https://{region}.example.com/v1/
+    variables:
+      region:
+        default: eu
+paths:
+  /users/{user-id}/posts:
+    parameters:
+      - $ref: '#/components/parameters/UserID'
+    get:
+      operationId: listPosts
+      summary: List posts of user
+      parameters:
+        - name: status
+          in: query
+          required: true
+          schema:
+            type: string
+            enum: [draft, published]
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+        - name: since
+          in: query
+          schema:
+            type: string
+            format: date-time
+        - name: limit
+          in: query
+          description: page size
+          schema:
+            type: integer
+            minimum: 1
+            maximum: 100
+            default: 20
+        - name: session
+          in: cookie
+          schema:
+            type: string
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Post'
+components:
+  parameters:
+    UserID:
+      name: user-id
+      in: path
+      required: true
+      description: user identifier
+      schema:
+        type: integer
+  schemas:
+    Post:
+      type: object
+      required: [title]
+      properties:
+        title:
+          type: string
+          pattern: '^(?=x)'
+        draft:
+          type: [boolean, "null"]
+        author:
+          type: object
+`
+
+const swaggerDocumentJSON = `{
+	"swagger": "2.0",
+	"host": "api.example.com",
+	"basePath": "/v2",
+	"schemes": ["http"],
+	"paths": {
+		"/pets": {
+			"post": {
+				"operationId": "addPet",
+				"consumes": ["application/x-www-form-urlencoded"],
+				"parameters": [
+					{"name": "name", "in": "formData", "type": "string", "required": true},
+					{"name": "ids", "in": "query", "type": "array", "items": {"type": "integer"}, "collectionFormat": "pipes"},
+					{"name": "age", "in": "query", "type": "integer", "default": 1}
+				]
+			}
+		}
+	}
+}`
+
+func TestImportOpenAPI(t *testing.T) {
+	// проверяем построение ручек из OpenAPI документа
+	logger := discardLogger()
+	container, err := ImportOpenAPI(strings.NewReader(openAPIDocumentYAML), ImportOptions{}, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	names := make([]string, 0, len(container))
+	for name := range container {
+		names = append(names, name)
+	}
+	if len(container) != 2 {
+		t.Fatalf("expected 2 hands got %v", names)
+	}
+
+	list := container["listPosts"]
+	if list.URLTemplate != `https://eu.example.com/v1/users/{{ index . "user-id" }}/posts` {
+		t.Errorf("unexpected url template %s", list.URLTemplate)
+	}
+	if list.Method != "" || list.Help != "List posts of user" || list.Body != DefaultImportedBody {
+		t.Errorf("unexpected hand %+v", list)
+	}
+	min, max := 1.0, 100.0
+	expectedParams := ParamsDescription{
+		"user-id": {Name: "user-id", Help: "user identifier", Destination: URLPlaced, Type: IntegerType},
+		"status":  {Name: "status", Destination: QueryPlaced, Type: EnumType, Choices: []string{"draft", "published"}},
+		"tags":    {Name: "tags", Destination: QueryPlaced, Type: ListType, ElementType: StringType, Optional: true},
+		"since":   {Name: "since", Destination: QueryPlaced, Type: DateTimeType, Layout: "RFC3339", Optional: true},
+		"limit": {Name: "limit", Help: "page size", Destination: QueryPlaced, Type: IntegerType, Optional: true,
+			Min: &min, Max: &max, DefaultValue: 20},
+	}
+	if !reflect.DeepEqual(list.Parameters, expectedParams) {
+		t.Errorf("expected params %+v got %+v", expectedParams, list.Parameters)
+	}
+
+	// имя ручки без operationId строится из метода и пути,
+	// вложенные объекты и неподдерживаемые шаблоны пропускаются
+	post, ok := container["post_users_user-id_posts"]
+	if !ok {
+		t.Fatalf("expected hand generated from path got %v", names)
+	}
+	if post.Method != http.MethodPost || post.RequestBody == nil || post.RequestBody.GetContentType() != JSONContent {
+		t.Errorf("unexpected request body of %+v", post)
+	}
+	expectedBody := ParamsDescription{
+		"title": {Name: "title", Destination: BodyPlaced, Type: StringType},
+		"draft": {Name: "draft", Destination: BodyPlaced, Type: BoolType, Optional: true},
+	}
+	for name, param := range expectedBody {
+		if !reflect.DeepEqual(post.Parameters[name], param) {
+			t.Errorf("expected body param %+v got %+v", param, post.Parameters[name])
+		}
+	}
+	if _, ok := post.Parameters["author"]; ok {
+		t.Errorf("expected object property to be skipped")
+	}
+
+	// сгенерированные описания загружаются как обычный файл описаний
+	var output strings.Builder
+	err = WriteDescriptionsYAML(&output, container)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if _, err = GetDescriptionSourceFromYAML(strings.NewReader(output.String())); err != nil {
+		t.Errorf("failed to load generated descriptions %s:\n%s", err.Error(), output.String())
+	}
+
+	_, err = ImportOpenAPI(strings.NewReader("paths: {}"), ImportOptions{}, logger)
+	if err != ErrNotOpenAPIDocument {
+		t.Errorf("expected not OpenAPI error got %v", err)
+	}
+}
+
+func TestOpenAPIDescriptionSource(t *testing.T) {
+	// проверяем запрос ручки, построенной из Swagger документа
+	var gotQuery, gotForm string
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		gotQuery = req.URL.Path + "?" + req.URL.RawQuery
+		body, _ := ioutil.ReadAll(req.Body)
+		gotForm = string(body)
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"id": 7}`))
+	}))
+	defer serv.Close()
+
+	logger := discardLogger()
+	options := ImportOptions{BaseURL: serv.URL + "/v2/", Body: "created {{ .responce.id }}"}
+	source, err := GetDescriptionSourceFromOpenAPI(strings.NewReader(swaggerDocumentJSON), options, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	processor := NewURLProcessor(source, serv.Client())
+	hand, err := processor.GetHand("addPet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var builder strings.Builder
+	err = hand.Process(context.Background(), &builder, map[string]interface{}{"name": "rex"}, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if builder.String() != "created 7" {
+		t.Errorf("expected overridden body got %s", builder.String())
+	}
+	if gotQuery != "/v2/pets?age=1" || gotForm != "name=rex" {
+		t.Errorf("unexpected request %s %s", gotQuery, gotForm)
+	}
+}
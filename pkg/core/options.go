@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultOptionsTTL time loaded param options are cached if it isn't specified
+const DefaultOptionsTTL = 30 * time.Second
+
+// OptionsSource description of the request param options are loaded from,
+// options are taken from the list found by ItemsPath in it's responce
+type OptionsSource struct {
+	// Hand name of the hand to request, or URL of GET request
+	Hand string `json:"hand" yaml:"hand"`
+	URL  string `json:"url" yaml:"url"`
+	// Params templated values of the hand params or query params of URL,
+	// templates get values of already entered params, URL template too
+	Params    map[string]string `json:"params" yaml:"params"`
+	ItemsPath string            `json:"items_path" yaml:"items_path"`
+	// ValuePath path to option value in item, item itself is used if it's empty
+	ValuePath string `json:"value_path" yaml:"value_path"`
+	// LabelPath path to option label in item, or LabelTemplate rendered with item
+	LabelPath     string   `json:"label_path" yaml:"label_path"`
+	LabelTemplate string   `json:"label_template" yaml:"label_template"`
+	TTL           Duration `json:"ttl" yaml:"ttl"`
+}
+
+// GetTTL get time loaded options are cached
+func (source *OptionsSource) GetTTL() time.Duration {
+	if source.TTL == 0 {
+		return DefaultOptionsTTL
+	}
+	return time.Duration(source.TTL)
+}
+
+// String describe source in help
+func (source *OptionsSource) String() string {
+	if source.Hand != "" {
+		return "hand " + source.Hand
+	}
+	return source.URL
+}
+
+type optionsCacheEntry struct {
+	options   []ParamOption
+	expiresAt time.Time
+}
+
+// optionsCache short-living storage of loaded param options
+type optionsCache struct {
+	mutex   sync.Mutex
+	entries map[string]optionsCacheEntry
+}
+
+func newOptionsCache() *optionsCache {
+	return &optionsCache{
+		entries: make(map[string]optionsCacheEntry),
+	}
+}
+
+func (cache *optionsCache) get(key string) ([]ParamOption, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.options, true
+}
+
+func (cache *optionsCache) set(key string, options []ParamOption, ttl time.Duration) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	now := time.Now()
+	for entryKey, entry := range cache.entries {
+		if now.After(entry.expiresAt) {
+			delete(cache.entries, entryKey)
+		}
+	}
+	cache.entries[key] = optionsCacheEntry{
+		options:   options,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+//GetParamOptions get options of the hand param, options of params with
+// options_from are loaded with request and cached, static options are
+// returned for other params
+func (processor *URLProcessor) GetParamOptions(ctx context.Context, handName string, paramName string, values map[string]interface{}, logger *log.Entry) ([]ParamOption, error) {
+	hand, err := processor.GetHand(handName)
+	if err != nil {
+		return nil, err
+	}
+	param, err := hand.GetParam(paramName)
+	if err != nil {
+		return nil, err
+	}
+	source := param.GetInfo().OptionsFrom
+	if source == nil {
+		return param.Options(), nil
+	}
+
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	requestParams := values
+	if source.Hand != "" {
+		sourceRecord, err := processor.container.GetByName(source.Hand)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get options hand %s: %w", source.Hand, err)
+		}
+		requestParams, err = renderOptionsParams(source, sourceRecord, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+	encodedParams, err := json.Marshal(requestParams)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to build options cache key %w", err)
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%s", handName, paramName, encodedParams)
+	if options, ok := processor.options.get(key); ok {
+		logger.Debugf("Using cached options of param %s", paramName)
+		return options, nil
+	}
+
+	options, err := processor.loadOptions(ctx, hand.GetInfo(), paramName, source, requestParams, logger)
+	if err != nil {
+		return nil, err
+	}
+	processor.options.set(key, options, source.GetTTL())
+	return options, nil
+}
+
+// renderOptionsParams render params of options hand with entered values,
+// values are parsed and checked as values of the options hand params
+func renderOptionsParams(source *OptionsSource, sourceRecord *URLRecord, values map[string]interface{}) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(source.Params))
+	for name, valueTemplate := range source.Params {
+		rendered, err := renderTemplate(name, valueTemplate, values)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to build options param %s: %w", name, err)
+		}
+		info, ok := sourceRecord.Parameters[name]
+		if !ok || rendered == "" {
+			params[name] = rendered
+			continue
+		}
+		value, err := parseValue(info, rendered)
+		if err == nil {
+			err = checkConstraints(&info, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to build options param %s: %w", name, err)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// loadOptions request options source and extract options from responce,
+// plain url is requested with auth of the hand the param belongs to
+func (processor *URLProcessor) loadOptions(ctx context.Context, hand *URLRecord, paramName string, source *OptionsSource, params map[string]interface{}, logger *log.Entry) ([]ParamOption, error) {
+	var record *URLRecord
+	if source.Hand != "" {
+		sourceRecord, err := processor.container.GetByName(source.Hand)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get options hand %s: %w", source.Hand, err)
+		}
+		record = sourceRecord
+	} else {
+		record = &URLRecord{
+			URLTemplate:  source.URL,
+			URLName:      fmt.Sprintf("%s.%s.options", hand.URLName, paramName),
+			Auth:         hand.Auth,
+			secretValues: hand.secretValues,
+		}
+	}
+	sourceProcessor := newHandProcessor(record, processor.httpClient, processor.tokens, processor.cache)
+	sourceLogger := logger.WithField("options_of", paramName)
+	if source.Hand != "" {
+		err := sourceProcessor.mergeWithDefault(ctx, params, sourceLogger)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load options of %s: %w", paramName, err)
+		}
+	} else {
+		// url and query of plain request are rendered with entered values
+		sourceProcessor.query = source.Params
+	}
+
+	responce, displayURL, _, err := sourceProcessor.fetch(ctx, params, sourceLogger)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load options of %s: %w", paramName, err)
+	}
+	if isErrorStatus(responce.Status) {
+		return nil, fmt.Errorf("Failed to load options of %s: %s responded with status %d", paramName, displayURL, responce.Status)
+	}
+	return extractOptions(source, responce.Data)
+}
+
+// extractOptions get options from items of decoded responce
+func extractOptions(source *OptionsSource, data interface{}) ([]ParamOption, error) {
+	value, ok := lookupPath(data, source.ItemsPath)
+	if !ok {
+		return nil, fmt.Errorf("No options found by path %s", source.ItemsPath)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Value by path %s is not a list", source.ItemsPath)
+	}
+	var labelTemplate *template.Template
+	if source.LabelTemplate != "" {
+		var err error
+		labelTemplate, err = template.New("label").Funcs(templateFuncs(nil)).Parse(source.LabelTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse label template %w", err)
+		}
+	}
+
+	options := make([]ParamOption, 0, len(items))
+	for _, item := range items {
+		optionValue, ok := lookupPath(item, source.ValuePath)
+		if !ok || optionValue == nil {
+			continue
+		}
+		option := ParamOption{Value: toString(optionValue)}
+		switch {
+		case labelTemplate != nil:
+			label, err := executeToString(labelTemplate, item)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to build option label %w", err)
+			}
+			option.Label = label
+		case source.LabelPath != "":
+			label, _ := lookupPath(item, source.LabelPath)
+			option.Label = toString(label)
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+func validateOptionsSource(source *OptionsSource) []error {
+	errs := make([]error, 0)
+	if (source.Hand == "") == (source.URL == "") {
+		errs = append(errs, errors.New("options_from requires either hand or url"))
+	}
+	if source.TTL < 0 {
+		errs = append(errs, errors.New("options ttl can't be negative"))
+	}
+	if source.LabelTemplate != "" {
+		_, err := template.New("label").Funcs(templateFuncs(nil)).Parse(source.LabelTemplate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid label template %w", err))
+		}
+	}
+	return errs
+}
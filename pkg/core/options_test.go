@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestParamOptionsFrom(t *testing.T) {
+	// проверяем загрузку вариантов значений параметра другим запросом и их кеширование
+	var requests int32
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var result interface{}
+		switch req.URL.Path {
+		case "/tenants":
+			result = map[string]interface{}{
+				"data": []interface{}{
+					map[string]interface{}{"id": "t1", "name": "First"},
+					map[string]interface{}{"id": "t2", "name": "Second"},
+				},
+			}
+		case "/deployments":
+			// варианты по url запрашиваются с аутентификацией ручки
+			if req.Header.Get("Authorization") != "Bearer options-token" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			result = []interface{}{req.URL.Query().Get("tenant") + "-api", req.URL.Query().Get("tenant") + "-web"}
+		case "/regions":
+			result = []interface{}{"eu-" + req.URL.Query().Get("size") + "-" + req.URL.Query().Get("limit")}
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(result)
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+	os.Setenv("HANDWITCH_TEST_OPTIONS_TOKEN", "options-token")
+	defer os.Unsetenv("HANDWITCH_TEST_OPTIONS_TOKEN")
+	maxLimit := 10.0
+
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"tenants": {
+			URLTemplate: serv.URL + "/tenants",
+			Body:        "{{ .responce }}",
+			URLName:     "tenants",
+		},
+		"regions": {
+			URLTemplate: serv.URL + `/regions?size={{ if gt .limit 5 }}big{{ else }}small{{ end }}`,
+			Parameters: ParamsDescription{
+				"limit": ParamInfo{
+					Name:        "limit",
+					Type:        IntegerType,
+					Destination: QueryPlaced,
+					Max:         &maxLimit,
+				},
+			},
+			Body:    "{{ .responce }}",
+			URLName: "regions",
+		},
+		"deploy": {
+			URLTemplate: serv.URL + "/deploy",
+			Auth: &AuthInfo{
+				Type:  BearerAuth,
+				Token: SecretValue{Env: "HANDWITCH_TEST_OPTIONS_TOKEN"},
+			},
+			Parameters: ParamsDescription{
+				"tenant": ParamInfo{
+					Name:        "tenant",
+					Type:        StringType,
+					Destination: QueryPlaced,
+					OptionsFrom: &OptionsSource{
+						Hand:          "tenants",
+						ItemsPath:     "data",
+						ValuePath:     "id",
+						LabelTemplate: "{{ .name }} ({{ .id }})",
+					},
+				},
+				"deployment": ParamInfo{
+					Name:        "deployment",
+					Type:        StringType,
+					Destination: QueryPlaced,
+					OptionsFrom: &OptionsSource{
+						URL:    serv.URL + "/deployments",
+						Params: map[string]string{"tenant": "{{ .tenant }}"},
+					},
+				},
+				"broken": ParamInfo{
+					Name:        "broken",
+					Type:        StringType,
+					Destination: QueryPlaced,
+					OptionsFrom: &OptionsSource{
+						URL: serv.URL + "/missing",
+					},
+				},
+				"zone": ParamInfo{
+					Name:        "zone",
+					Type:        StringType,
+					Destination: QueryPlaced,
+					OptionsFrom: &OptionsSource{
+						Hand:   "regions",
+						Params: map[string]string{"limit": "{{ .count }}"},
+					},
+				},
+				"region": ParamInfo{
+					Name:        "region",
+					Type:        EnumType,
+					Destination: QueryPlaced,
+					Choices:     []string{"eu"},
+				},
+			},
+			Body:    "{{ .responce }}",
+			URLName: "deploy",
+		},
+	}), serv.Client())
+
+	logger := log.NewEntry(&log.Logger{})
+	testCases := []struct {
+		Param    string
+		Values   map[string]interface{}
+		Options  []ParamOption
+		HasError bool
+	}{
+		{
+			Param: "tenant",
+			Options: []ParamOption{
+				{Value: "t1", Label: "First (t1)"},
+				{Value: "t2", Label: "Second (t2)"},
+			},
+		},
+		{
+			Param:  "deployment",
+			Values: map[string]interface{}{"tenant": "t2"},
+			Options: []ParamOption{
+				{Value: "t2-api"},
+				{Value: "t2-web"},
+			},
+		},
+		{
+			// значения параметров ручки вариантов приводятся к их типам
+			Param:   "zone",
+			Values:  map[string]interface{}{"count": 3},
+			Options: []ParamOption{{Value: "eu-small-3"}},
+		},
+		{Param: "zone", Values: map[string]interface{}{"count": 30}, HasError: true},
+		{Param: "broken", HasError: true},
+		{Param: "region", Options: []ParamOption{{Value: "eu"}}},
+	}
+	for _, testCase := range testCases {
+		options, err := processor.GetParamOptions(context.Background(), "deploy", testCase.Param, testCase.Values, logger)
+		if (err != nil) != testCase.HasError {
+			t.Errorf("%s: unexpected error state %v", testCase.Param, err)
+			continue
+		}
+		if !testCase.HasError && !reflect.DeepEqual(options, testCase.Options) {
+			t.Errorf("%s: expected %v got %v", testCase.Param, testCase.Options, options)
+		}
+	}
+
+	before := atomic.LoadInt32(&requests)
+	_, err := processor.GetParamOptions(context.Background(), "deploy", "tenant", nil, logger)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if atomic.LoadInt32(&requests) != before {
+		t.Errorf("options are not cached")
+	}
+}
@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PaginationType way to find the next page of the list
+type PaginationType string
+
+const (
+	//CursorPagination next page cursor or link is taken from responce
+	CursorPagination PaginationType = "cursor"
+	//LinkPagination next page link is taken from Link header
+	LinkPagination PaginationType = "link"
+	//OffsetPagination next page offset or number is calculated
+	OffsetPagination PaginationType = "offset"
+)
+
+// DefaultMaxPages maximum number of fetched pages if it isn't specified
+const DefaultMaxPages = 10
+
+// PaginationInfo description of paged list hand, all pages are
+// fetched one by one and merged into one list
+type PaginationInfo struct {
+	Type PaginationType `json:"type" yaml:"type"`
+	// ItemsPath path to list of items in page responce, page responce
+	// is the list itself if it isn't specified
+	ItemsPath string `json:"items_path" yaml:"items_path"`
+	// CursorPath path to next page cursor in page responce
+	CursorPath string `json:"cursor_path" yaml:"cursor_path"`
+	// CursorParam query param to pass cursor, if it isn't specified
+	// value by CursorPath is used as next page link
+	CursorParam string `json:"cursor_param" yaml:"cursor_param"`
+	// OffsetParam query param with offset or page number
+	OffsetParam string `json:"offset_param" yaml:"offset_param"`
+	// LimitParam query param with page size
+	LimitParam string `json:"limit_param" yaml:"limit_param"`
+	Limit      int    `json:"limit" yaml:"limit"`
+	Start      int    `json:"start" yaml:"start"`
+	// Step offset increment, page size by default or 1 without page size
+	Step     int `json:"step" yaml:"step"`
+	MaxPages int `json:"max_pages" yaml:"max_pages"`
+}
+
+// GetMaxPages get maximum number of fetched pages
+func (pagination *PaginationInfo) GetMaxPages() int {
+	if pagination.MaxPages == 0 {
+		return DefaultMaxPages
+	}
+	return pagination.MaxPages
+}
+
+// GetStep get offset increment
+func (pagination *PaginationInfo) GetStep() int {
+	switch {
+	case pagination.Step != 0:
+		return pagination.Step
+	case pagination.Limit != 0:
+		return pagination.Limit
+	}
+	return 1
+}
+
+// pageRequest overrides of the next page request
+type pageRequest struct {
+	url   string
+	query url.Values
+}
+
+// apply set page query params to request
+func (page *pageRequest) apply(req *http.Request) {
+	if page == nil || len(page.query) == 0 {
+		return
+	}
+	qry := req.URL.Query()
+	for name, values := range page.query {
+		qry[name] = values
+	}
+	req.URL.RawQuery = qry.Encode()
+}
+
+// cacheSuffix part of cache key to distinguish pages
+func (page *pageRequest) cacheSuffix() string {
+	if page == nil || len(page.query) == 0 {
+		return ""
+	}
+	return "#" + page.query.Encode()
+}
+
+// firstPage build request of the first page
+func (pagination *PaginationInfo) firstPage() *pageRequest {
+	if pagination.Type != OffsetPagination {
+		return nil
+	}
+	return pagination.offsetPage(pagination.Start)
+}
+
+func (pagination *PaginationInfo) offsetPage(offset int) *pageRequest {
+	query := url.Values{}
+	query.Set(pagination.OffsetParam, strconv.Itoa(offset))
+	if pagination.LimitParam != "" && pagination.Limit != 0 {
+		query.Set(pagination.LimitParam, strconv.Itoa(pagination.Limit))
+	}
+	return &pageRequest{query: query}
+}
+
+// nextPage build request of the page after fetched one,
+// nil is returned if there is no next page
+func (pagination *PaginationInfo) nextPage(current *pageRequest, pageURL string, responce *handResponce, items []interface{}) (*pageRequest, error) {
+	switch pagination.Type {
+	case CursorPagination:
+		cursor, ok := lookupPath(responce.Data, pagination.CursorPath)
+		if !ok || cursor == nil || fmt.Sprintf("%v", cursor) == "" {
+			return nil, nil
+		}
+		if pagination.CursorParam == "" {
+			return linkPage(pageURL, fmt.Sprintf("%v", cursor))
+		}
+		query := url.Values{}
+		query.Set(pagination.CursorParam, fmt.Sprintf("%v", cursor))
+		return &pageRequest{query: query}, nil
+	case LinkPagination:
+		link := nextLink(responce.Header["Link"])
+		if link == "" {
+			return nil, nil
+		}
+		return linkPage(pageURL, link)
+	case OffsetPagination:
+		if len(items) == 0 || (pagination.Limit != 0 && len(items) < pagination.Limit) {
+			return nil, nil
+		}
+		offset, err := strconv.Atoi(current.query.Get(pagination.OffsetParam))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get current offset %w", err)
+		}
+		return pagination.offsetPage(offset + pagination.GetStep()), nil
+	}
+	return nil, fmt.Errorf("unsupported pagination type %s", pagination.Type)
+}
+
+// nextLink find link with rel="next" in Link header values
+func nextLink(values []string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "rel=") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimPrefix(param, "rel="), `"`)) {
+					if rel == "next" {
+						return strings.Trim(target, "<>")
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// linkPage build request of the page by link relative to current page,
+// link to other scheme or host is rejected, hand credentials and headers
+// would be sent there otherwise
+func linkPage(pageURL string, link string) (*pageRequest, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse page URL %w", err)
+	}
+	next, err := base.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse next page link %w", err)
+	}
+	if next.Scheme != base.Scheme || next.Host != base.Host {
+		return nil, fmt.Errorf("next page link %s leads to other host than %s://%s", next.Redacted(), base.Scheme, base.Host)
+	}
+	return &pageRequest{url: next.String()}, nil
+}
+
+// pageItems get list of items from decoded page
+func (pagination *PaginationInfo) pageItems(data interface{}) ([]interface{}, error) {
+	value, ok := lookupPath(data, pagination.ItemsPath)
+	if !ok || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Value by items path %s is not a list", pagination.ItemsPath)
+	}
+	return items, nil
+}
+
+// fetch send hand request and decode responce, all pages
+// of paginated hand are fetched and merged into one list,
+// url of the first page is returned masked to be shown to user
+func (processor *HandProcessorImp) fetch(ctx context.Context, params map[string]interface{}, logger *log.Entry) (*handResponce, string, bool, error) {
+	if processor.Pagination == nil {
+		responce, requestURL, cached, err := processor.sendCached(ctx, params, logger)
+		if err != nil {
+			return nil, "", false, err
+		}
+		err = processor.decode(responce, logger)
+		return responce, processor.maskSecrets(requestURL), cached, err
+	}
+
+	pagination := processor.Pagination
+	pageProcessor := *processor
+	pageProcessor.page = pagination.firstPage()
+	items := make([]interface{}, 0)
+	var firstURL string
+	allCached := true
+	merged := &handResponce{}
+	for page := 0; page < pagination.GetMaxPages(); page++ {
+		pageLogger := logger.WithField("page", page+1)
+		responce, requestURL, cached, err := pageProcessor.sendCached(ctx, params, pageLogger)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("Failed to fetch page %d: %w", page+1, err)
+		}
+		err = pageProcessor.decode(responce, pageLogger)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("Failed to decode page %d: %w", page+1, err)
+		}
+		if isErrorStatus(responce.Status) {
+			// failed page is shown as the hand responce
+			return responce, processor.maskSecrets(requestURL), cached, nil
+		}
+		if page == 0 {
+			firstURL = processor.maskSecrets(requestURL)
+		}
+		allCached = allCached && cached
+		pageItems, err := pagination.pageItems(responce.Data)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("Failed to get items of page %d: %w", page+1, err)
+		}
+		items = append(items, pageItems...)
+		merged.Status = responce.Status
+		merged.Header = responce.Header
+		merged.Elapsed += responce.Elapsed
+		merged.Pages = page + 1
+
+		// links are resolved against real url, masked one may miss host
+		next, err := pagination.nextPage(pageProcessor.page, requestURL, responce, pageItems)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("Failed to get next page after page %d: %w", page+1, err)
+		}
+		if next == nil {
+			break
+		}
+		if page+1 == pagination.GetMaxPages() {
+			pageLogger.Warnf("Pages limit %d reached, rest of the list is skipped", pagination.GetMaxPages())
+		}
+		pageProcessor.page = next
+	}
+	merged.Data = items
+	return merged, firstURL, allCached, nil
+}
+
+func validatePagination(pagination *PaginationInfo) []error {
+	errs := make([]error, 0)
+	switch pagination.Type {
+	case CursorPagination:
+		if pagination.CursorPath == "" {
+			errs = append(errs, errors.New("cursor pagination requires cursor_path"))
+		}
+	case LinkPagination:
+	case OffsetPagination:
+		if pagination.OffsetParam == "" {
+			errs = append(errs, errors.New("offset pagination requires offset_param"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported pagination type %s, expected one of cursor, link, offset", pagination.Type))
+	}
+	if pagination.MaxPages < 0 {
+		errs = append(errs, errors.New("max_pages can't be negative"))
+	}
+	if pagination.Limit < 0 || pagination.Step < 0 {
+		errs = append(errs, errors.New("pagination limit and step can't be negative"))
+	}
+	return errs
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestPagination(t *testing.T) {
+	// проверяем обход страниц всеми способами и ограничение числа страниц
+	items := []string{"a", "b", "c", "d", "e"}
+	const pageSize = 2
+	page := func(offset int) []string {
+		if offset >= len(items) {
+			return []string{}
+		}
+		end := offset + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		return items[offset:end]
+	}
+	writeJSON := func(rw http.ResponseWriter, value interface{}) {
+		err := json.NewEncoder(rw).Encode(value)
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		qry := req.URL.Query()
+		switch req.URL.Path {
+		case "/cursor":
+			offset, _ := strconv.Atoi(qry.Get("cursor"))
+			next := ""
+			if offset+pageSize < len(items) {
+				next = strconv.Itoa(offset + pageSize)
+			}
+			writeJSON(rw, map[string]interface{}{
+				"data": map[string]interface{}{"items": page(offset)},
+				"next": next,
+			})
+		case "/next":
+			offset, _ := strconv.Atoi(qry.Get("from"))
+			result := map[string]interface{}{"items": page(offset)}
+			if offset+pageSize < len(items) {
+				result["next"] = fmt.Sprintf("next?from=%d", offset+pageSize)
+			}
+			writeJSON(rw, result)
+		case "/link":
+			offset, _ := strconv.Atoi(qry.Get("from"))
+			if offset+pageSize < len(items) {
+				rw.Header().Set("Link", fmt.Sprintf(`</link?from=%d>; rel="next", </link?from=0>; rel="first"`, offset+pageSize))
+			}
+			writeJSON(rw, page(offset))
+		case "/offset":
+			number, _ := strconv.Atoi(qry.Get("page"))
+			size, _ := strconv.Atoi(qry.Get("per_page"))
+			if size != pageSize {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			writeJSON(rw, map[string]interface{}{"items": page((number - 1) * pageSize)})
+		}
+	}))
+	defer serv.Close()
+
+	body := `{{ range .responce }}{{ . }}{{ end }} {{ .meta.pages }}`
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"cursor": {
+			URLTemplate: serv.URL + "/cursor",
+			Pagination: &PaginationInfo{
+				Type:        CursorPagination,
+				ItemsPath:   "data.items",
+				CursorPath:  "$.next",
+				CursorParam: "cursor",
+			},
+			Body:    body,
+			URLName: "cursor",
+		},
+		"next_link": {
+			URLTemplate: serv.URL + "/next",
+			Pagination: &PaginationInfo{
+				Type:       CursorPagination,
+				ItemsPath:  "items",
+				CursorPath: "next",
+			},
+			Body:    body,
+			URLName: "next_link",
+		},
+		"link": {
+			URLTemplate: serv.URL + "/link",
+			Pagination: &PaginationInfo{
+				Type: LinkPagination,
+			},
+			Body:    body,
+			URLName: "link",
+		},
+		"offset": {
+			URLTemplate: serv.URL + "/offset",
+			Pagination: &PaginationInfo{
+				Type:        OffsetPagination,
+				ItemsPath:   "items",
+				OffsetParam: "page",
+				LimitParam:  "per_page",
+				Limit:       pageSize,
+				Start:       1,
+				Step:        1,
+			},
+			Body:    body,
+			URLName: "offset",
+		},
+		"limited": {
+			URLTemplate: serv.URL + "/link",
+			Pagination: &PaginationInfo{
+				Type:     LinkPagination,
+				MaxPages: 2,
+			},
+			Body:    body,
+			URLName: "limited",
+		},
+	}), serv.Client())
+
+	testCases := []struct {
+		Hand   string
+		Output string
+	}{
+		{Hand: "cursor", Output: "abcde 3"},
+		{Hand: "next_link", Output: "abcde 3"},
+		{Hand: "link", Output: "abcde 3"},
+		{Hand: "offset", Output: "abcde 3"},
+		{Hand: "limited", Output: "abcd 2"},
+	}
+	for _, testCase := range testCases {
+		hand, err := processor.GetHand(testCase.Hand)
+		if err != nil {
+			t.Fatalf("%s: failed to get hand %s", testCase.Hand, err.Error())
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Hand, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output %q got %q", testCase.Hand, testCase.Output, buf.String())
+		}
+	}
+}
+
+func TestPaginationWithSecretHost(t *testing.T) {
+	// проверяем, что ссылка на следующую страницу разрешается
+	// относительно настоящего адреса, а не скрытого
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "" {
+			rw.Header().Set("Link", `</items?page=2>; rel="next"`)
+			fmt.Fprint(rw, `["a"]`)
+			return
+		}
+		fmt.Fprint(rw, `["b"]`)
+	}))
+	defer serv.Close()
+
+	description := `hand:
+  url_template: ${HOST}/items
+  pagination:
+    type: link
+  body: "{{ range .responce }}{{ . }}{{ end }} {{ .meta.url }}"
+  url_name: hand
+  help: ""`
+	source, err := GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), mapSecrets{"HOST": serv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	processor := NewURLProcessor(source, serv.Client())
+	hand, err := processor.GetHand("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if buf.String() != "ab ***/items" {
+		t.Errorf("expected output %q got %q", "ab ***/items", buf.String())
+	}
+}
+
+func TestPaginationLinkToOtherHost(t *testing.T) {
+	// проверяем, что учётные данные ручки не уходят на другой хост по ссылке на следующую страницу
+	os.Setenv("HANDWITCH_TEST_TOKEN", "token")
+	defer os.Unsetenv("HANDWITCH_TEST_TOKEN")
+
+	var leaked int32
+	other := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "" {
+			atomic.AddInt32(&leaked, 1)
+		}
+		fmt.Fprint(rw, `[]`)
+	}))
+	defer other.Close()
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Link", fmt.Sprintf(`<%s/steal>; rel="next"`, other.URL))
+		fmt.Fprint(rw, `["a"]`)
+	}))
+	defer serv.Close()
+
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"hand": {
+			URLTemplate: serv.URL + "/items",
+			Auth:        &AuthInfo{Type: BearerAuth, Token: SecretValue{Env: "HANDWITCH_TEST_TOKEN"}},
+			Pagination:  &PaginationInfo{Type: LinkPagination},
+			Body:        `{{ range .responce }}{{ . }}{{ end }}`,
+			URLName:     "hand",
+		},
+	}), serv.Client())
+	hand, err := processor.GetHand("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+	if err == nil || !strings.Contains(err.Error(), "leads to other host") {
+		t.Errorf("expected other host error got %v", err)
+	}
+	if atomic.LoadInt32(&leaked) != 0 {
+		t.Errorf("authorization header is sent to other host")
+	}
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultListSeparator separator of list param elements if it isn't specified
+const defaultListSeparator = ","
+
+// defaultLayouts layouts of date params if they aren't specified
+var defaultLayouts = map[ParamType]string{
+	DateType:     "2006-01-02",
+	DateTimeType: "2006-01-02 15:04",
+}
+
+var boolValues = map[string]bool{
+	"true": true, "yes": true, "y": true, "on": true, "1": true, "да": true,
+	"false": false, "no": false, "n": false, "off": false, "0": false, "нет": false,
+}
+
+// GetLayout get layout of date param
+func (info *ParamInfo) GetLayout() string {
+	if info.Layout != "" {
+		return getLayout(info.Layout)
+	}
+	return defaultLayouts[info.Type]
+}
+
+// GetSeparator get separator of list param elements
+func (info *ParamInfo) GetSeparator() string {
+	if info.Separator == "" {
+		return defaultListSeparator
+	}
+	return info.Separator
+}
+
+// GetElementType get type of list param elements
+func (info *ParamInfo) GetElementType() ParamType {
+	if info.ElementType == "" {
+		return StringType
+	}
+	return info.ElementType
+}
+
+// elementInfo description of list param element
+func (info *ParamInfo) elementInfo() ParamInfo {
+	return ParamInfo{
+		Name:    info.Name,
+		Type:    info.GetElementType(),
+		Layout:  info.Layout,
+		Choices: info.Choices,
+	}
+}
+
+// TimeValue value of date params, it's formatted with param layout
+// when placed into url, query or body
+type TimeValue struct {
+	time.Time
+	Layout string
+}
+
+// String format time with param layout
+func (value TimeValue) String() string {
+	return value.Format(value.Layout)
+}
+
+// MarshalJSON write time formatted with param layout
+func (value TimeValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.String())
+}
+
+// ListValue value of list params, it's placed into query
+// and form body as repeated keys
+type ListValue []interface{}
+
+// String join elements with default separator
+func (value ListValue) String() string {
+	parts := make([]string, 0, len(value))
+	for _, element := range value {
+		parts = append(parts, toString(element))
+	}
+	return strings.Join(parts, defaultListSeparator)
+}
+
+// paramStrings get param value representations for query and form,
+// list elements are represented separately
+func paramStrings(value interface{}) []string {
+	if list, ok := value.(ListValue); ok {
+		result := make([]string, 0, len(list))
+		for _, element := range list {
+			result = append(result, toString(element))
+		}
+		return result
+	}
+	return []string{fmt.Sprintf("%v", value)}
+}
+
+func parseFloat(str string) (interface{}, error) {
+	return strconv.ParseFloat(strings.TrimSpace(str), 64)
+}
+
+func parseBool(str string) (interface{}, error) {
+	value, ok := boolValues[strings.ToLower(strings.TrimSpace(str))]
+	if !ok {
+		return nil, fmt.Errorf("%q is not a boolean, expected true or false", str)
+	}
+	return value, nil
+}
+
+func parseDate(info ParamInfo, str string) (interface{}, error) {
+	layout := info.GetLayout()
+	value, err := time.Parse(layout, strings.TrimSpace(str))
+	if err != nil {
+		return nil, fmt.Errorf("%q doesn't match format %s", str, layout)
+	}
+	return TimeValue{Time: value, Layout: layout}, nil
+}
+
+func parseDurationParam(str string) (interface{}, error) {
+	value, err := time.ParseDuration(strings.TrimSpace(str))
+	if err != nil {
+		return nil, err
+	}
+	return Duration(value), nil
+}
+
+func parseEnum(info ParamInfo, str string) (interface{}, error) {
+	str = strings.TrimSpace(str)
+	for _, choice := range info.Choices {
+		if strings.EqualFold(choice, str) {
+			return choice, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not one of %s", str, strings.Join(info.Choices, ", "))
+}
+
+func parseList(info ParamInfo, str string) (interface{}, error) {
+	element := info.elementInfo()
+	result := make(ListValue, 0)
+	for _, part := range strings.Split(str, info.GetSeparator()) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := parseValue(element, part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list element %w", err)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// ParamOption one of allowed param values offered to user,
+// Label is shown to user instead of Value if it's set
+type ParamOption struct {
+	Value string
+	Label string
+}
+
+// GetLabel get text shown to user
+func (option ParamOption) GetLabel() string {
+	if option.Label == "" {
+		return option.Value
+	}
+	return option.Label
+}
+
+//Options get allowed values of the param, values of one_of
+// constraint, enum choices or boolean values
+func (p *ParamProcessorImp) Options() []ParamOption {
+	values := make([]string, 0)
+	switch {
+	case len(p.OneOf) != 0:
+		for _, value := range p.OneOf {
+			values = append(values, toString(value))
+		}
+	case p.Type == EnumType:
+		values = append(values, p.Choices...)
+	case p.Type == BoolType:
+		values = append(values, "true", "false")
+	}
+	options := make([]ParamOption, 0, len(values))
+	for _, value := range values {
+		options = append(options, ParamOption{Value: value})
+	}
+	return options
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestParseParamTypes(t *testing.T) {
+	// проверяем разбор значений всех типов параметров из строки и из значений по умолчанию
+	date := time.Date(2020, 9, 13, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Name     string
+		Info     ParamInfo
+		Input    interface{}
+		Result   interface{}
+		HasError bool
+	}{
+		{Name: "float", Info: ParamInfo{Type: FloatType}, Input: "1.5", Result: 1.5},
+		{Name: "float from yaml int", Info: ParamInfo{Type: FloatType}, Input: 2, Result: 2.0},
+		{Name: "broken float", Info: ParamInfo{Type: FloatType}, Input: "a", HasError: true},
+		{Name: "integer from json number", Info: ParamInfo{Type: IntegerType}, Input: float64(3), Result: 3},
+		{Name: "fractional integer", Info: ParamInfo{Type: IntegerType}, Input: 3.5, HasError: true},
+		{Name: "bool", Info: ParamInfo{Type: BoolType}, Input: "Yes", Result: true},
+		{Name: "bool from yaml", Info: ParamInfo{Type: BoolType}, Input: false, Result: false},
+		{Name: "broken bool", Info: ParamInfo{Type: BoolType}, Input: "maybe", HasError: true},
+		{
+			Name:   "date",
+			Info:   ParamInfo{Type: DateType},
+			Input:  "2020-09-13",
+			Result: TimeValue{Time: date, Layout: "2006-01-02"},
+		},
+		{
+			Name:   "date with layout",
+			Info:   ParamInfo{Type: DateType, Layout: "02.01.2006"},
+			Input:  "13.09.2020",
+			Result: TimeValue{Time: date, Layout: "02.01.2006"},
+		},
+		{
+			Name:   "datetime",
+			Info:   ParamInfo{Type: DateTimeType},
+			Input:  "2020-09-13 12:30",
+			Result: TimeValue{Time: date.Add(12*time.Hour + 30*time.Minute), Layout: "2006-01-02 15:04"},
+		},
+		{Name: "broken date", Info: ParamInfo{Type: DateType}, Input: "13.09.2020", HasError: true},
+		{Name: "duration", Info: ParamInfo{Type: DurationType}, Input: "1h30m", Result: Duration(90 * time.Minute)},
+		{Name: "duration from seconds", Info: ParamInfo{Type: DurationType}, Input: 5, Result: Duration(5 * time.Second)},
+		{Name: "enum", Info: ParamInfo{Type: EnumType, Choices: []string{"eu", "us"}}, Input: "EU", Result: "eu"},
+		{Name: "unknown enum", Info: ParamInfo{Type: EnumType, Choices: []string{"eu", "us"}}, Input: "asia", HasError: true},
+		{Name: "list", Info: ParamInfo{Type: ListType}, Input: "a, b,,c", Result: ListValue{"a", "b", "c"}},
+		{
+			Name:   "list of integers with separator",
+			Info:   ParamInfo{Type: ListType, ElementType: IntegerType, Separator: ";"},
+			Input:  "1;2",
+			Result: ListValue{1, 2},
+		},
+		{
+			Name:   "list from yaml",
+			Info:   ParamInfo{Type: ListType, ElementType: EnumType, Choices: []string{"eu", "us"}},
+			Input:  []interface{}{"us", "eu"},
+			Result: ListValue{"us", "eu"},
+		},
+		{Name: "list with broken element", Info: ParamInfo{Type: ListType, ElementType: IntegerType}, Input: "1,a", HasError: true},
+		{Name: "map default", Info: ParamInfo{Type: StringType}, Input: map[string]interface{}{}, HasError: true},
+	}
+	for _, testCase := range testCases {
+		result, err := parseFromInterface(testCase.Info, testCase.Input)
+		if (err != nil) != testCase.HasError {
+			t.Errorf("%s: unexpected error state %v", testCase.Name, err)
+			continue
+		}
+		if testCase.HasError {
+			continue
+		}
+		if !reflect.DeepEqual(result, testCase.Result) {
+			t.Errorf("%s: expected %#v got %#v", testCase.Name, testCase.Result, result)
+		}
+	}
+}
+
+func TestParamTypesHelp(t *testing.T) {
+	testCases := []struct {
+		Info   ParamInfo
+		Output string
+	}{
+		{
+			Info:   ParamInfo{Name: "region", Type: EnumType, Destination: QueryPlaced, Choices: []string{"eu", "us"}, Help: "region"},
+			Output: "region(Enum)\tQuery Param\n\tChoices: eu, us\n\tregion\n",
+		},
+		{
+			Info:   ParamInfo{Name: "days", Type: ListType, ElementType: DateType, Destination: QueryPlaced, Help: "days"},
+			Output: "days(List)\tQuery Param\n\tList of Date separated by \",\", Format: 2006-01-02\n\tdays\n",
+		},
+	}
+	for _, testCase := range testCases {
+		processor := NewParamProcessor(testCase.Info)
+		buf := new(bytes.Buffer)
+		err := processor.WriteHelp(buf)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Info.Name, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected %q got %q", testCase.Info.Name, testCase.Output, buf.String())
+		}
+	}
+}
+
+func TestParamTypesSerialization(t *testing.T) {
+	// проверяем передачу значений в запросе, списки передаются повторяющимися ключами
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{
+			"path":  req.URL.Path,
+			"query": req.URL.Query(),
+		})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	params := ParamsDescription{
+		"day":    ParamInfo{Name: "day", Type: DateType, Layout: "02.01.2006", Destination: URLPlaced},
+		"ids":    ParamInfo{Name: "ids", Type: ListType, ElementType: IntegerType, Destination: QueryPlaced},
+		"active": ParamInfo{Name: "active", Type: BoolType, Destination: QueryPlaced, DefaultValue: "yes"},
+		"period": ParamInfo{Name: "period", Type: DurationType, Destination: QueryPlaced, DefaultValue: 90},
+	}
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"typed": {
+			URLTemplate: serv.URL + "/{{ .day }}",
+			Parameters:  params,
+			Body:        `{{ .responce.path }} {{ .responce.query.ids }} {{ .responce.query.active }} {{ .responce.query.period }} {{ .meta.params.day.Year }}`,
+			URLName:     "typed",
+		},
+	}), serv.Client())
+	hand, err := processor.GetHand("typed")
+	if err != nil {
+		t.Fatalf("failed to get hand %s", err.Error())
+	}
+	values := make(map[string]interface{})
+	for name, input := range map[string]string{"day": "13.09.2020", "ids": "1, 2"} {
+		param, err := hand.GetParam(name)
+		if err != nil {
+			t.Fatalf("failed to get param %s", err.Error())
+		}
+		values[name], err = param.ParseFromString(input)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %s", name, err.Error())
+		}
+	}
+	buf := new(bytes.Buffer)
+	err = hand.Process(context.Background(), buf, values, log.NewEntry(&log.Logger{}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	expected := "/13.09.2020 [1 2] [true] [1m30s] 2020"
+	if buf.String() != expected {
+		t.Errorf("expected %q got %q", expected, buf.String())
+	}
+}
+
+func TestParamOptions(t *testing.T) {
+	testCases := []struct {
+		Info    ParamInfo
+		Options []ParamOption
+	}{
+		{Info: ParamInfo{Type: EnumType, Choices: []string{"eu", "us"}}, Options: []ParamOption{{Value: "eu"}, {Value: "us"}}},
+		{Info: ParamInfo{Type: EnumType, Choices: []string{"eu", "us"}, OneOf: []interface{}{"us"}}, Options: []ParamOption{{Value: "us"}}},
+		{Info: ParamInfo{Type: IntegerType, OneOf: []interface{}{1, 2}}, Options: []ParamOption{{Value: "1"}, {Value: "2"}}},
+		{Info: ParamInfo{Type: BoolType}, Options: []ParamOption{{Value: "true"}, {Value: "false"}}},
+		{Info: ParamInfo{Type: StringType}, Options: []ParamOption{}},
+	}
+	for _, testCase := range testCases {
+		processor := NewParamProcessor(testCase.Info)
+		options := processor.Options()
+		if !reflect.DeepEqual(options, testCase.Options) {
+			t.Errorf("%s: expected %v got %v", testCase.Info.Type, testCase.Options, options)
+		}
+	}
+}
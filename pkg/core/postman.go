@@ -0,0 +1,270 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotPostmanCollection document isn't Postman v2 collection
+var ErrNotPostmanCollection = errors.New("document is not a Postman v2 collection")
+
+// postmanText description or value, Postman writes them either as
+// string or as object with content, non string values are kept as json
+type postmanText string
+
+func (text *postmanText) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*text = postmanText(value)
+		return nil
+	}
+	var description struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &description); err == nil && description.Content != "" {
+		*text = postmanText(description.Content)
+		return nil
+	}
+	if string(data) != "null" {
+		*text = postmanText(data)
+	}
+	return nil
+}
+
+type postmanField struct {
+	Key         string      `json:"key"`
+	Value       postmanText `json:"value"`
+	Description postmanText `json:"description"`
+	Disabled    bool        `json:"disabled"`
+	Type        string      `json:"type"`
+}
+
+// postmanFields enabled fields as imported fields
+func postmanFields(fields []postmanField) []importedField {
+	result := make([]importedField, 0, len(fields))
+	for _, field := range fields {
+		if field.Disabled {
+			continue
+		}
+		result = append(result, importedField{Key: field.Key, Value: string(field.Value), Help: string(field.Description)})
+	}
+	return result
+}
+
+// postmanValue value of field with key
+func postmanValue(fields []postmanField, key string) string {
+	for _, field := range fields {
+		if field.Key == key {
+			return string(field.Value)
+		}
+	}
+	return ""
+}
+
+type postmanURL struct {
+	Raw      string         `json:"raw"`
+	Query    []postmanField `json:"query"`
+	Variable []postmanField `json:"variable"`
+}
+
+func (postman *postmanURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &postman.Raw); err == nil {
+		return nil
+	}
+	type plainURL postmanURL
+	return json.Unmarshal(data, (*plainURL)(postman))
+}
+
+type postmanAuth struct {
+	Type   string         `json:"type"`
+	Bearer []postmanField `json:"bearer"`
+	Basic  []postmanField `json:"basic"`
+	APIKey []postmanField `json:"apikey"`
+}
+
+// imported credentials of auth, nil is returned for noauth
+func (auth *postmanAuth) imported(logger *log.Entry) *importedAuth {
+	switch auth.Type {
+	case "noauth":
+		return nil
+	case "bearer":
+		return &importedAuth{Type: BearerAuth, Secret: postmanValue(auth.Bearer, "token")}
+	case "basic":
+		return &importedAuth{
+			Type:     BasicAuth,
+			Username: postmanValue(auth.Basic, "username"),
+			Secret:   postmanValue(auth.Basic, "password"),
+		}
+	case "apikey":
+		imported := &importedAuth{
+			Type:   APIKeyAuth,
+			Name:   postmanValue(auth.APIKey, "key"),
+			Secret: postmanValue(auth.APIKey, "value"),
+		}
+		if postmanValue(auth.APIKey, "in") == "query" {
+			imported.In = QueryPlaced
+		}
+		return imported
+	}
+	logger.Warnf("Postman auth %s isn't supported", auth.Type)
+	return nil
+}
+
+type postmanBody struct {
+	Mode       string         `json:"mode"`
+	Raw        string         `json:"raw"`
+	URLEncoded []postmanField `json:"urlencoded"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanRequest struct {
+	Method      string         `json:"method"`
+	Header      []postmanField `json:"header"`
+	URL         postmanURL     `json:"url"`
+	Body        *postmanBody   `json:"body"`
+	Auth        *postmanAuth   `json:"auth"`
+	Description postmanText    `json:"description"`
+}
+
+func (request *postmanRequest) UnmarshalJSON(data []byte) error {
+	// request can be written as url string
+	if err := json.Unmarshal(data, &request.URL.Raw); err == nil {
+		return nil
+	}
+	type plainRequest postmanRequest
+	return json.Unmarshal(data, (*plainRequest)(request))
+}
+
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description postmanText     `json:"description"`
+	Item        []postmanItem   `json:"item"`
+	Request     *postmanRequest `json:"request"`
+	Auth        *postmanAuth    `json:"auth"`
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem  `json:"item"`
+	Variable []postmanField `json:"variable"`
+	Auth     *postmanAuth   `json:"auth"`
+}
+
+// postmanImporter converts requests of collection into hands
+type postmanImporter struct {
+	converter requestConverter
+	container URLContrainer
+}
+
+// importItems import requests of items and folders, auth of
+// folder is used by requests which don't specify it
+func (importer *postmanImporter) importItems(items []postmanItem, folders []string, auth *postmanAuth) {
+	for _, item := range items {
+		itemAuth := auth
+		if item.Auth != nil && item.Auth.Type != "inherit" {
+			itemAuth = item.Auth
+		}
+		if item.Request == nil {
+			path := append(append(make([]string, 0, len(folders)+1), folders...), item.Name)
+			importer.importItems(item.Item, path, itemAuth)
+			continue
+		}
+		importer.container.addUnique(importer.convertRequest(&item, folders, itemAuth))
+	}
+}
+
+// convertRequest build hand from request of the item
+func (importer *postmanImporter) convertRequest(item *postmanItem, folders []string, auth *postmanAuth) URLRecord {
+	postman := item.Request
+	logger := importer.converter.logger
+	request := &importedRequest{
+		Name:    sanitizeHandName(strings.ToLower(item.Name)),
+		Help:    strings.Join(append(append([]string{}, folders...), item.Name), " / "),
+		Method:  postman.Method,
+		URL:     postman.URL.Raw,
+		Headers: postmanFields(postman.Header),
+	}
+	for _, description := range []postmanText{item.Description, postman.Description} {
+		if description != "" {
+			request.Help += "\n" + string(description)
+		}
+	}
+	if postman.URL.Query != nil {
+		request.Query = postmanFields(postman.URL.Query)
+	}
+	if postman.Auth != nil && postman.Auth.Type != "inherit" {
+		auth = postman.Auth
+	}
+	if auth != nil {
+		request.Auth = auth.imported(logger)
+	}
+	if body := postman.Body; body != nil {
+		switch body.Mode {
+		case "raw":
+			request.Body = &importedBody{Raw: body.Raw}
+			if body.Options.Raw.Language == "json" {
+				request.Body.ContentType = string(JSONContent)
+			}
+		case "urlencoded":
+			request.Body = &importedBody{ContentType: string(FormContent), Form: postmanFields(body.URLEncoded)}
+		case "":
+		default:
+			logger.Warnf("Body of request %s is skipped: %s body isn't supported", item.Name, body.Mode)
+		}
+	}
+
+	// path variables are known only in the request
+	converter := importer.converter
+	converter.variables = make(map[string]importedField, len(importer.converter.variables))
+	for name, variable := range importer.converter.variables {
+		converter.variables[name] = variable
+	}
+	for _, variable := range postmanFields(postman.URL.Variable) {
+		converter.variables[variable.Key] = variable
+	}
+	return converter.convert(request)
+}
+
+//ImportPostman build hands from requests of Postman v2.0 or v2.1 collection,
+// request names are used as hand names and {{variables}} become hand params
+// with values of collection variables as default values
+func ImportPostman(reader io.Reader, options ImportOptions, logger *log.Entry) (URLContrainer, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	collection := postmanCollection{}
+	err = json.Unmarshal(data, &collection)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse Postman collection %w", err)
+	}
+	if !strings.Contains(collection.Info.Schema, "/collection/v2") {
+		return nil, ErrNotPostmanCollection
+	}
+	importer := postmanImporter{
+		converter: requestConverter{
+			options:   options,
+			variables: make(map[string]importedField),
+			logger:    logger,
+		},
+		container: make(URLContrainer),
+	}
+	for _, variable := range postmanFields(collection.Variable) {
+		importer.converter.variables[variable.Key] = variable
+	}
+	importer.importItems(collection.Item, nil, collection.Auth)
+	return importer.container, nil
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+const postmanCollectionJSON = `{
+	"info": {
+		"name": "Blog",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{apiToken}}", "type": "string"}]},
+	"variable": [
+		{"key": "baseUrl", "value": "https://blog.example.com/api"},
+		{"key": "apiToken", "value": "t0ken"},
+		{"key": "limit", "value": 20}
+	],
+	"item": [
+		{
+			"name": "Posts",
+			"item": [
+				{
+					"name": "Get post",
+					"request": {
+						"method": "GET",
+						"description": {"content": "Post by id", "type": "text/plain"},
+						"url": {
+							"raw": "{{baseUrl}}/posts/:postId?limit={{limit}}&debug=1",
+							"host": ["{{baseUrl}}"],
+							"path": ["posts", ":postId"],
+							"query": [
+								{"key": "limit", "value": "{{limit}}"},
+								{"key": "debug", "value": "1", "disabled": true}
+							],
+							"variable": [{"key": "postId", "value": "1", "description": "post identifier"}]
+						}
+					}
+				},
+				{
+					"name": "Create comment",
+					"request": {
+						"auth": {"type": "noauth"},
+						"method": "POST",
+						"header": [{"key": "X-Trace", "value": "{{$guid}}"}],
+						"body": {"mode": "urlencoded", "urlencoded": [{"key": "text", "value": "{{text}}"}, {"key": "lang", "value": "en"}]},
+						"url": "{{baseUrl}}/comments"
+					}
+				}
+			]
+		},
+		{"name": "Health", "request": "https://blog.example.com/health"}
+	]
+}`
+
+func TestImportPostman(t *testing.T) {
+	// проверяем построение ручек из коллекции Postman
+	container, err := ImportPostman(strings.NewReader(postmanCollectionJSON), ImportOptions{}, discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if len(container) != 3 {
+		t.Fatalf("expected 3 hands got %d", len(container))
+	}
+
+	post := container["get_post"]
+	if post.URLTemplate != "{{ .baseUrl }}/posts/{{ .postId }}" || post.Help != "Posts / Get post\nPost by id" {
+		t.Errorf("unexpected hand %+v", post)
+	}
+	if post.Auth == nil || post.Auth.Type != BearerAuth || post.Auth.Token.Env != "APITOKEN" {
+		t.Errorf("expected collection auth got %+v", post.Auth)
+	}
+	// значения переменных коллекции становятся значениями по умолчанию
+	if post.Parameters["baseUrl"].DefaultValue != "https://blog.example.com/api" {
+		t.Errorf("expected base url default value got %+v", post.Parameters["baseUrl"])
+	}
+	postID := post.Parameters["postId"]
+	if postID.Destination != URLPlaced || postID.DefaultValue != "1" || postID.Help != "post identifier" {
+		t.Errorf("unexpected path variable %+v", postID)
+	}
+	if limit := post.Parameters["limit"]; limit.Destination != QueryPlaced || limit.DefaultValue != "20" {
+		t.Errorf("unexpected query param %+v", limit)
+	}
+	if _, ok := post.Parameters["debug"]; ok {
+		t.Errorf("expected disabled query param to be skipped")
+	}
+
+	comment := container["create_comment"]
+	if comment.Auth != nil || comment.GetMethod() != http.MethodPost {
+		t.Errorf("unexpected hand %+v", comment)
+	}
+	if comment.RequestBody == nil || comment.RequestBody.GetContentType() != FormContent {
+		t.Errorf("expected form body got %+v", comment.RequestBody)
+	}
+	if text := comment.Parameters["text"]; text.Destination != BodyPlaced || text.Optional {
+		t.Errorf("unexpected body param %+v", text)
+	}
+	if comment.Headers["X-Trace"] != `{{ "{{" }}$guid}}` {
+		t.Errorf("expected dynamic variable to be kept as text got %s", comment.Headers["X-Trace"])
+	}
+
+	if container["health"].URLTemplate != "https://blog.example.com/health" {
+		t.Errorf("unexpected hand %+v", container["health"])
+	}
+
+	// адрес сервиса заменяется опцией
+	container, err = ImportPostman(strings.NewReader(postmanCollectionJSON), ImportOptions{BaseURL: "http://localhost:8080/"}, discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if container["get_post"].URLTemplate != "http://localhost:8080/posts/{{ .postId }}" {
+		t.Errorf("expected rebased url got %s", container["get_post"].URLTemplate)
+	}
+
+	var output strings.Builder
+	err = WriteDescriptionsYAML(&output, container)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	// секреты авторизации проверяются при загрузке описаний
+	_, err = GetDescriptionSourceFromYAML(strings.NewReader(output.String()))
+	if err == nil || !strings.Contains(err.Error(), "APITOKEN") {
+		t.Errorf("expected missing auth secret error got %v", err)
+	}
+	os.Setenv("APITOKEN", "secret")
+	defer os.Unsetenv("APITOKEN")
+	if _, err = GetDescriptionSourceFromYAML(strings.NewReader(output.String())); err != nil {
+		t.Errorf("failed to load generated descriptions %s:\n%s", err.Error(), output.String())
+	}
+
+	_, err = ImportPostman(strings.NewReader(`{"info": {"name": "old"}, "requests": []}`), ImportOptions{}, discardLogger())
+	if err != ErrNotPostmanCollection {
+		t.Errorf("expected not collection error got %v", err)
+	}
+}
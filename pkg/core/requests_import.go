@@ -0,0 +1,522 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// placeholder Postman style variable {{name}}, quotes around
+	// it are captured to replace whole json string with value
+	placeholder = regexp.MustCompile(`("?)\{\{\s*([^{}\s"]+)\s*\}\}("?)`)
+	// pathVariable Postman path variable like /users/:id
+	pathVariable = regexp.MustCompile(`/:([A-Za-z_][A-Za-z0-9_]*)`)
+	// urlOrigin scheme and host of url or variable url starts with
+	urlOrigin = regexp.MustCompile(`^(?:[A-Za-z][A-Za-z0-9+.-]*://[^/?#]*|\{\{[^{}]+\}\})`)
+	// credentialHeader headers which usually carry credentials
+	credentialHeader = regexp.MustCompile(`(?i)token|key|secret|password|cookie`)
+	// envSymbols symbols which can't be used in environment variable name
+	envSymbols = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// importedField key and value of query param, header or form field
+type importedField struct {
+	Key   string
+	Value string
+	Help  string
+}
+
+// importedBody body of imported request, it's either raw text or form fields
+type importedBody struct {
+	ContentType string
+	Raw         string
+	Form        []importedField
+}
+
+// importedAuth credentials of imported request, secret is
+// literal password, token or key or variable placeholder
+type importedAuth struct {
+	Type     AuthType
+	Username string
+	Secret   string
+	Name     string
+	In       ParamDestination
+}
+
+// importedRequest request described by curl command or Postman collection,
+// query is parsed from url if it's nil, values may contain {{variables}}
+type importedRequest struct {
+	Name    string
+	Help    string
+	Method  string
+	URL     string
+	Query   []importedField
+	Headers []importedField
+	Body    *importedBody
+	Auth    *importedAuth
+	Timeout Duration
+	Retries int
+}
+
+// requestConverter converts imported requests into hands
+type requestConverter struct {
+	options ImportOptions
+	// variables default values and descriptions of placeholders
+	variables map[string]importedField
+	logger    *log.Entry
+}
+
+// wholePlaceholder name of the variable if text is a single placeholder
+func wholePlaceholder(text string) (string, bool) {
+	match := placeholder.FindStringSubmatch(text)
+	if match == nil || match[0] != text || match[1] != "" || match[3] != "" || strings.HasPrefix(match[2], "$") {
+		return "", false
+	}
+	return match[2], true
+}
+
+// toTemplate convert placeholders in text into template references, in json
+// quoted placeholder is replaced with json value, other "{{" are escaped
+func (conv *requestConverter) toTemplate(text string, isJSON bool) (string, []string) {
+	var builder strings.Builder
+	variables := make([]string, 0)
+	escape := func(text string) {
+		builder.WriteString(strings.Replace(text, "{{", `{{ "{{" }}`, -1))
+	}
+	last := 0
+	for _, match := range placeholder.FindAllStringSubmatchIndex(text, -1) {
+		escape(text[last:match[0]])
+		last = match[1]
+		openQuote, name, closeQuote := text[match[2]:match[3]], text[match[4]:match[5]], text[match[6]:match[7]]
+		if strings.HasPrefix(name, "$") {
+			conv.logger.Warnf("Dynamic variable %s isn't supported, it's kept as text", name)
+			escape(text[match[0]:match[1]])
+			continue
+		}
+		variables = append(variables, name)
+		if isJSON && openQuote != "" && closeQuote != "" {
+			builder.WriteString("{{ toJson " + templateReference(name) + " }}")
+			continue
+		}
+		builder.WriteString(openQuote + "{{ " + templateReference(name) + " }}" + closeQuote)
+	}
+	escape(text[last:])
+	return builder.String(), variables
+}
+
+// addParam add param to hand, param used only in templates is
+// replaced with param with the same name placed into request
+func addParam(hand *URLRecord, info ParamInfo) {
+	if existing, ok := hand.Parameters[info.Name]; ok {
+		if existing.Destination != URLPlaced || info.Destination == URLPlaced {
+			return
+		}
+	}
+	hand.Parameters[info.Name] = info
+}
+
+// variableParams add params of variables used in templates
+func (conv *requestConverter) variableParams(hand *URLRecord, variables []string) {
+	for _, name := range variables {
+		addParam(hand, conv.variableParam(name, name, URLPlaced))
+	}
+}
+
+// variableParam param with value of the variable, default value
+// and description of the variable are used if they are known
+func (conv *requestConverter) variableParam(paramName string, variable string, destination ParamDestination) ParamInfo {
+	info := ParamInfo{Name: paramName, Destination: destination, Type: StringType}
+	if known, ok := conv.variables[variable]; ok {
+		info.Help = known.Help
+		if known.Value != "" && !strings.Contains(known.Value, "{{") {
+			info.DefaultValue = known.Value
+		}
+	}
+	if info.Help == "" && paramName != variable {
+		info.Help = "variable " + variable
+	}
+	return info
+}
+
+// guessValue parse literal into number or boolean if it looks like one
+func guessValue(value string) (interface{}, ParamType) {
+	if number, err := strconv.Atoi(value); err == nil {
+		return number, IntegerType
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number, FloatType
+	}
+	if value == "true" || value == "false" {
+		return value == "true", BoolType
+	}
+	return value, StringType
+}
+
+// fieldParam param of query, header or body field, literal value is used
+// as a default value, value with variables is a templated default value
+func (conv *requestConverter) fieldParam(hand *URLRecord, field importedField, destination ParamDestination, guess bool) ParamInfo {
+	if variable, ok := wholePlaceholder(field.Value); ok {
+		info := conv.variableParam(field.Key, variable, destination)
+		if field.Help != "" {
+			info.Help = field.Help
+		}
+		info.Optional = info.DefaultValue != nil
+		return info
+	}
+	info := ParamInfo{Name: field.Key, Help: field.Help, Destination: destination, Type: StringType, Optional: true}
+	template, variables := conv.toTemplate(field.Value, false)
+	if len(variables) != 0 {
+		info.DefaultValue = template
+		conv.variableParams(hand, variables)
+		return info
+	}
+	if guess {
+		info.DefaultValue, info.Type = guessValue(field.Value)
+	} else {
+		info.DefaultValue = field.Value
+	}
+	return info
+}
+
+// addFieldParam add param of query or form field, repeated
+// literal fields are turned into list param
+func (conv *requestConverter) addFieldParam(hand *URLRecord, field importedField, destination ParamDestination) {
+	info := conv.fieldParam(hand, field, destination, true)
+	existing, ok := hand.Parameters[field.Key]
+	if !ok || existing.Destination != destination || existing.DefaultValue == nil || info.DefaultValue == nil ||
+		isTemplateValue(info.DefaultValue) || isTemplateValue(existing.DefaultValue) {
+		addParam(hand, info)
+		return
+	}
+	if existing.Type != ListType {
+		existing.ElementType = existing.Type
+		existing.Type = ListType
+		existing.DefaultValue = []interface{}{existing.DefaultValue}
+	}
+	if list, ok := existing.DefaultValue.([]interface{}); ok {
+		if value, elementType := guessValue(field.Value); elementType == existing.ElementType {
+			existing.DefaultValue = append(list, value)
+		} else {
+			existing.ElementType = StringType
+			existing.DefaultValue = append(stringValues(list), field.Value)
+		}
+	}
+	hand.Parameters[field.Key] = existing
+}
+
+func stringValues(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, fmt.Sprintf("%v", value))
+	}
+	return result
+}
+
+// parseQuery split url-encoded query or form into fields
+func parseQuery(query string) []importedField {
+	fields := make([]importedField, 0)
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		parts := strings.SplitN(part, "=", 2)
+		field := importedField{Key: parts[0]}
+		if len(parts) == 2 {
+			field.Value = parts[1]
+		}
+		if key, err := url.QueryUnescape(field.Key); err == nil {
+			field.Key = key
+		}
+		if value, err := url.QueryUnescape(field.Value); err == nil {
+			field.Value = value
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// isFormBody check if raw body looks like url-encoded form
+func isFormBody(raw string) bool {
+	for _, part := range strings.Split(raw, "&") {
+		key := strings.SplitN(part, "=", 2)[0]
+		if !strings.Contains(part, "=") || key == "" || strings.ContainsAny(key, " \n\t") {
+			return false
+		}
+	}
+	return raw != ""
+}
+
+// jsonParam param of top level field of json body, ok is
+// false if value can't be represented as a param
+func (conv *requestConverter) jsonParam(hand *URLRecord, key string, value interface{}) (ParamInfo, bool) {
+	info := ParamInfo{Name: key, Destination: BodyPlaced, Optional: true, DefaultValue: value}
+	switch typed := value.(type) {
+	case string:
+		return conv.fieldParam(hand, importedField{Key: key, Value: typed}, BodyPlaced, false), true
+	case nil:
+		info.Type = StringType
+	case bool:
+		info.Type = BoolType
+	case float64:
+		info.Type = FloatType
+		if typed == float64(int(typed)) {
+			info.Type = IntegerType
+			info.DefaultValue = int(typed)
+		}
+	case []interface{}:
+		info.Type = ListType
+		elements := make([]interface{}, 0, len(typed))
+		for i, element := range typed {
+			elementInfo, ok := conv.jsonParam(hand, key, element)
+			if !ok || elementInfo.Type == ListType || isTemplateValue(elementInfo.DefaultValue) || (i != 0 && elementInfo.Type != info.ElementType) {
+				return info, false
+			}
+			info.ElementType = elementInfo.Type
+			elements = append(elements, elementInfo.DefaultValue)
+		}
+		info.DefaultValue = elements
+	default:
+		return info, false
+	}
+	return info, true
+}
+
+// convertBody describe request body, json object with plain fields and
+// form are described with body params, other bodies with template
+func (conv *requestConverter) convertBody(hand *URLRecord, body *importedBody) {
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(body.ContentType, ";")[0]))
+	form := body.Form
+	if form == nil && contentType == string(FormContent) && isFormBody(body.Raw) {
+		form = parseQuery(body.Raw)
+	}
+	if form != nil {
+		hand.RequestBody = &RequestBody{ContentType: FormContent}
+		for _, field := range form {
+			conv.addFieldParam(hand, field, BodyPlaced)
+		}
+		return
+	}
+	if body.Raw == "" {
+		return
+	}
+	trimmed := strings.TrimSpace(body.Raw)
+	isJSON := contentType == string(JSONContent) || strings.HasSuffix(contentType, "+json") ||
+		(contentType == "" && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")))
+	if isJSON {
+		hand.RequestBody = &RequestBody{ContentType: JSONContent}
+		fields := make(map[string]interface{})
+		if json.Unmarshal([]byte(body.Raw), &fields) == nil {
+			params := make([]ParamInfo, 0, len(fields))
+			for key, value := range fields {
+				info, ok := conv.jsonParam(hand, key, value)
+				if !ok {
+					params = nil
+					break
+				}
+				params = append(params, info)
+			}
+			if params != nil {
+				for _, info := range params {
+					addParam(hand, info)
+				}
+				return
+			}
+		}
+		template, variables := conv.toTemplate(body.Raw, true)
+		hand.RequestBody.Template = template
+		conv.variableParams(hand, variables)
+		return
+	}
+	template, variables := conv.toTemplate(body.Raw, false)
+	hand.RequestBody = &RequestBody{ContentType: TextContent, Template: template}
+	conv.variableParams(hand, variables)
+	if contentType != "" && contentType != string(TextContent) {
+		// header is set after content type of the body
+		hand.Headers["Content-Type"] = body.ContentType
+	}
+}
+
+// secretEnv environment variable with secret of the hand,
+// name of the variable is used if secret is a placeholder
+func secretEnv(handName string, secret string, suffix string) string {
+	if variable, ok := wholePlaceholder(secret); ok {
+		return strings.Trim(strings.ToUpper(envSymbols.ReplaceAllString(variable, "_")), "_")
+	}
+	return strings.Trim(strings.ToUpper(envSymbols.ReplaceAllString(handName, "_")), "_") + "_" + suffix
+}
+
+// headerAuth get credentials from Authorization header, nil
+// is returned if header can't be described with hand auth
+func headerAuth(value string) *importedAuth {
+	parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	switch strings.ToLower(parts[0]) {
+	case "bearer":
+		return &importedAuth{Type: BearerAuth, Secret: strings.TrimSpace(parts[1])}
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil
+		}
+		credentials := strings.SplitN(string(decoded), ":", 2)
+		if len(credentials) != 2 {
+			return nil
+		}
+		return &importedAuth{Type: BasicAuth, Username: credentials[0], Secret: credentials[1]}
+	}
+	return nil
+}
+
+// convertAuth describe auth of the hand, secrets are read from
+// environment variables instead of being written into description
+func (conv *requestConverter) convertAuth(hand *URLRecord, auth *importedAuth) {
+	info := &AuthInfo{Type: auth.Type, Name: auth.Name, In: auth.In}
+	var secret *SecretValue
+	var suffix string
+	switch auth.Type {
+	case BasicAuth:
+		info.Username = auth.Username
+		if variable, ok := wholePlaceholder(auth.Username); ok {
+			info.Username = conv.variables[variable].Value
+		}
+		if info.Username == "" || strings.Contains(info.Username, "{{") {
+			conv.logger.Warnf("Username of hand %s auth is unknown, it should be set in description", hand.URLName)
+			info.Username = "username"
+		}
+		secret, suffix = &info.Password, "PASSWORD"
+	case BearerAuth:
+		secret, suffix = &info.Token, "TOKEN"
+	case APIKeyAuth:
+		secret, suffix = &info.Key, "KEY"
+	default:
+		conv.logger.Warnf("Auth %s of hand %s isn't supported", auth.Type, hand.URLName)
+		return
+	}
+	secret.Env = secretEnv(hand.URLName, auth.Secret, suffix)
+	conv.logger.Warnf("Secret of hand %s auth is read from environment variable %s", hand.URLName, secret.Env)
+	hand.Auth = info
+}
+
+// rebase replace scheme and host of url with base url option
+func (conv *requestConverter) rebase(rawURL string) string {
+	if conv.options.BaseURL == "" {
+		return rawURL
+	}
+	base := strings.TrimSuffix(conv.options.BaseURL, "/")
+	if origin := urlOrigin.FindString(rawURL); origin != "" {
+		return base + rawURL[len(origin):]
+	}
+	return base + "/" + strings.TrimPrefix(rawURL, "/")
+}
+
+// convert build hand from imported request
+func (conv *requestConverter) convert(request *importedRequest) URLRecord {
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	rawURL := request.URL
+	if i := strings.Index(rawURL, "#"); i != -1 {
+		rawURL = rawURL[:i]
+	}
+	query := request.Query
+	if i := strings.Index(rawURL, "?"); i != -1 {
+		if query == nil {
+			query = parseQuery(rawURL[i+1:])
+		}
+		rawURL = rawURL[:i]
+	}
+	rawURL = conv.rebase(pathVariable.ReplaceAllString(rawURL, "/{{$1}}"))
+
+	name := request.Name
+	if name == "" {
+		name = pathHandName(method, rawURL[len(urlOrigin.FindString(rawURL)):])
+	}
+	hand := URLRecord{
+		Method:     method,
+		Headers:    make(map[string]string),
+		Parameters: make(ParamsDescription),
+		Body:       conv.options.body(),
+		URLName:    name,
+		Help:       request.Help,
+		Timeout:    request.Timeout,
+		Retries:    request.Retries,
+	}
+	if method == http.MethodGet {
+		hand.Method = ""
+	}
+	if method != http.MethodGet && validateMethod(method) != nil {
+		conv.logger.Warnf("Method %s of hand %s isn't supported, GET is used", method, name)
+		hand.Method = ""
+	}
+
+	var variables []string
+	hand.URLTemplate, variables = conv.toTemplate(rawURL, false)
+	conv.variableParams(&hand, variables)
+	for _, field := range query {
+		conv.addFieldParam(&hand, field, QueryPlaced)
+	}
+
+	auth := request.Auth
+	body := request.Body
+	for _, header := range request.Headers {
+		switch strings.ToLower(header.Key) {
+		case "content-type":
+			if body != nil {
+				copied := *body
+				copied.ContentType = header.Value
+				body = &copied
+				continue
+			}
+		case "authorization":
+			if auth == nil {
+				if auth = headerAuth(header.Value); auth != nil {
+					continue
+				}
+			}
+		}
+		if _, ok := wholePlaceholder(header.Value); ok {
+			addParam(&hand, conv.fieldParam(&hand, header, HeaderPlaced, false))
+			continue
+		}
+		if credentialHeader.MatchString(header.Key) || strings.EqualFold(header.Key, "authorization") {
+			// credentials aren't written into description, like auth secrets
+			suffix := strings.Trim(strings.ToUpper(envSymbols.ReplaceAllString(header.Key, "_")), "_")
+			env := secretEnv(name, header.Value, suffix)
+			hand.Headers[header.Key] = "${" + env + "}"
+			conv.logger.Warnf("Header %s of hand %s may contain credentials, it's read from environment variable %s", header.Key, name, env)
+			continue
+		}
+		template, variables := conv.toTemplate(header.Value, false)
+		hand.Headers[header.Key] = template
+		conv.variableParams(&hand, variables)
+	}
+
+	if body != nil {
+		if hand.GetMethod() == http.MethodGet || hand.GetMethod() == http.MethodHead {
+			conv.logger.Warnf("Body of hand %s is skipped: body can't be sent with %s", name, hand.GetMethod())
+		} else {
+			conv.convertBody(&hand, body)
+		}
+	}
+	if auth != nil {
+		conv.convertAuth(&hand, auth)
+	}
+	if len(hand.Headers) == 0 {
+		hand.Headers = nil
+	}
+	if len(hand.Parameters) == 0 {
+		hand.Parameters = nil
+	}
+	return hand
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// secretsKeySize size of the key of encrypted secrets file, AES-256 is used
+const secretsKeySize = 32
+
+// maskedSecret replaces secret values in urls shown to user
+const maskedSecret = "***"
+
+// minMaskedSecretLength shorter secret values like "1" or "on" are not
+// masked, masking them would corrupt any text containing such symbols
+const minMaskedSecretLength = 4
+
+var (
+	// ErrSecretNotFound provider has no secret with requested name
+	ErrSecretNotFound = errors.New("Secret not found")
+	// ErrInvalidSecretsKey key of encrypted secrets file has wrong format
+	ErrInvalidSecretsKey = errors.New("Secrets key should be 32 bytes encoded with hex or base64")
+
+	// envReference reference like ${NAME}, $${NAME} is kept as is without first $
+	envReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_./-]*)\}`)
+	// templateAction action of go template
+	templateAction = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	// secretCall call of secret function with literal name inside template action
+	secretCall = regexp.MustCompile(`\bsecret\s+("(?:[^"\\]|\\.)*")`)
+)
+
+//SecretsProvider source of secrets referenced in hand descriptions
+// with ${NAME} and {{ secret "NAME" }}
+type SecretsProvider interface {
+	GetSecret(name string) (string, error)
+}
+
+//EnvSecretsProvider get secrets from environment variables,
+// Prefix is added to secret name
+type EnvSecretsProvider struct {
+	Prefix string
+}
+
+//GetSecret get value of environment variable
+func (provider EnvSecretsProvider) GetSecret(name string) (string, error) {
+	value, ok := os.LookupEnv(provider.Prefix + name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set: %w", provider.Prefix+name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+//DirectorySecretsProvider get secrets from files in directory,
+// file name is secret name, like docker and kubernetes secrets are mounted
+type DirectorySecretsProvider struct {
+	Dir string
+}
+
+//GetSecret read secret file, surrounding whitespaces are trimmed
+func (provider DirectorySecretsProvider) GetSecret(name string) (string, error) {
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %s", name)
+	}
+	value, err := ioutil.ReadFile(filepath.Join(provider.Dir, name))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret file %s is not found in %s: %w", name, provider.Dir, ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+//EncryptedFileSecretsProvider secrets from yaml map encrypted
+// with AES-256-GCM, file is decrypted once on creation
+type EncryptedFileSecretsProvider struct {
+	secrets map[string]string
+}
+
+//NewEncryptedFileSecretsProvider decrypt secrets file with key
+func NewEncryptedFileSecretsProvider(path string, key []byte) (*EncryptedFileSecretsProvider, error) {
+	encrypted, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read secrets file %w", err)
+	}
+	secrets, err := DecryptSecrets(encrypted, key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt secrets file %s: %w", path, err)
+	}
+	return &EncryptedFileSecretsProvider{secrets: secrets}, nil
+}
+
+//GetSecret get decrypted secret
+func (provider *EncryptedFileSecretsProvider) GetSecret(name string) (string, error) {
+	value, ok := provider.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s is not in encrypted file: %w", name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+//ParseSecretsKey decode key of encrypted secrets file from hex or base64
+func ParseSecretsKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == secretsKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == secretsKeySize {
+		return key, nil
+	}
+	return nil, ErrInvalidSecretsKey
+}
+
+func newSecretsCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != secretsKeySize {
+		return nil, ErrInvalidSecretsKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//EncryptSecrets encrypt secrets into content of encrypted secrets file,
+// it's base64 of nonce followed by sealed yaml map
+func EncryptSecrets(secrets map[string]string, key []byte) ([]byte, error) {
+	aead, err := newSecretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(encoded, sealed)
+	return encoded, nil
+}
+
+//DecryptSecrets decrypt content of encrypted secrets file
+func DecryptSecrets(encrypted []byte, key []byte) (map[string]string, error) {
+	aead, err := newSecretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encrypted)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encoding %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or corrupted data %w", err)
+	}
+	secrets := make(map[string]string)
+	err = yaml.Unmarshal(plain, &secrets)
+	if err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// interpolateSecrets replace secret references in text with values,
+// values of all references are returned to mask them in urls and help
+func interpolateSecrets(text string, secrets SecretsProvider) (string, []string, []error) {
+	errs := make([]error, 0)
+	masked := make([]string, 0)
+	text = templateAction.ReplaceAllStringFunc(text, func(action string) string {
+		return secretCall.ReplaceAllStringFunc(action, func(call string) string {
+			quoted := secretCall.FindStringSubmatch(call)[1]
+			name, err := strconv.Unquote(quoted)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid secret name %s", quoted))
+				return call
+			}
+			value, err := secrets.GetSecret(name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("secret %s: %w", name, err))
+				return call
+			}
+			masked = append(masked, value)
+			// secret is placed into template as string literal
+			return strconv.Quote(value)
+		})
+	})
+	text = envReference.ReplaceAllStringFunc(text, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		name := envReference.FindStringSubmatch(reference)[1]
+		value, err := secrets.GetSecret(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secret %s: %w", name, err))
+			return reference
+		}
+		masked = append(masked, value)
+		if strings.Contains(value, "{{") || strings.Contains(value, "}}") {
+			// value isn't interpreted as template
+			return "{{ " + strconv.Quote(value) + " }}"
+		}
+		return value
+	})
+	return text, masked, errs
+}
+
+// resolveSecrets interpolate secrets into url template, headers,
+// default values and computed values of the hand, auth secrets
+// are resolved with the same secrets provider
+func resolveSecrets(urlRecord *URLRecord, secrets SecretsProvider) []error {
+	errs := make([]error, 0)
+	if urlRecord.Auth != nil {
+		values, authErrs := resolveAuthSecrets(urlRecord.Auth, secrets)
+		urlRecord.secretValues = append(urlRecord.secretValues, values...)
+		errs = append(errs, authErrs...)
+	}
+	resolve := func(field string, text string) string {
+		result, masked, fieldErrs := interpolateSecrets(text, secrets)
+		for _, err := range fieldErrs {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+		urlRecord.secretValues = append(urlRecord.secretValues, masked...)
+		return result
+	}
+
+	urlRecord.URLTemplate = resolve("url_template", urlRecord.URLTemplate)
+	headers := make([]string, 0, len(urlRecord.Headers))
+	for header := range urlRecord.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		urlRecord.Headers[header] = resolve("header "+header, urlRecord.Headers[header])
+	}
+
+	paramNames := make([]string, 0, len(urlRecord.Parameters))
+	for paramName := range urlRecord.Parameters {
+		paramNames = append(paramNames, paramName)
+	}
+	sort.Strings(paramNames)
+	for _, paramName := range paramNames {
+		param := urlRecord.Parameters[paramName]
+		if defaultValue, ok := param.DefaultValue.(string); ok {
+			param.DefaultValue = resolve("default value of "+paramName, defaultValue)
+		}
+		if param.Computed != "" {
+			param.Computed = resolve("computed value of "+paramName, param.Computed)
+		}
+		urlRecord.Parameters[paramName] = param
+	}
+	return errs
+}
+
+// maskSecrets hide values of secrets in url or help shown to user
+func (urlRecord *URLRecord) maskSecrets(text string) string {
+	return maskSecretValues(urlRecord.secretValues, text)
+}
+
+// maskSecretValues hide secret values in text, values quoted
+// in templates and escaped in urls are hidden too
+func maskSecretValues(values []string, text string) string {
+	for _, value := range values {
+		if utf8.RuneCountInString(value) < minMaskedSecretLength {
+			continue
+		}
+		for _, form := range []string{strconv.Quote(value), value, url.QueryEscape(value), url.PathEscape(value)} {
+			text = strings.ReplaceAll(text, form, maskedSecret)
+		}
+	}
+	return text
+}
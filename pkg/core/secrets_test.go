@@ -0,0 +1,230 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type mapSecrets map[string]string
+
+func (secrets mapSecrets) GetSecret(name string) (string, error) {
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func TestInterpolateSecrets(t *testing.T) {
+	// проверяем подстановку секретов в шаблоны
+	secrets := mapSecrets{
+		"HOST":   "api.example.com",
+		"token":  "s3cr\"et",
+		"braces": "{{ .oops }}",
+	}
+	testCases := []struct {
+		Input  string
+		Output string
+		Masked []string
+		Errors int
+	}{
+		{Input: "https://${HOST}/{{ .id }}", Output: "https://api.example.com/{{ .id }}", Masked: []string{"api.example.com"}},
+		{Input: "kept $${HOST} and {{ $x := 1 }}{{ $x }}", Output: "kept ${HOST} and {{ $x := 1 }}{{ $x }}"},
+		{Input: `Bearer {{ secret "token" }}`, Output: `Bearer {{ "s3cr\"et" }}`, Masked: []string{"s3cr\"et"}},
+		{Input: `{{ secret "token" | upper }}`, Output: `{{ "s3cr\"et" | upper }}`, Masked: []string{"s3cr\"et"}},
+		{Input: `secret "token" outside action`, Output: `secret "token" outside action`},
+		{Input: "${braces}", Output: `{{ "{{ .oops }}" }}`, Masked: []string{"{{ .oops }}"}},
+		{Input: `${MISSING} {{ secret "missing" }}`, Output: `${MISSING} {{ secret "missing" }}`, Errors: 2},
+	}
+	for _, testCase := range testCases {
+		output, masked, errs := interpolateSecrets(testCase.Input, secrets)
+		if output != testCase.Output {
+			t.Errorf("%s: expected %s got %s", testCase.Input, testCase.Output, output)
+		}
+		if len(masked) != len(testCase.Masked) {
+			t.Errorf("%s: expected masked %v got %v", testCase.Input, testCase.Masked, masked)
+		}
+		if len(errs) != testCase.Errors {
+			t.Errorf("%s: expected %d errors got %v", testCase.Input, testCase.Errors, errs)
+		}
+	}
+}
+
+func TestSecretsProviders(t *testing.T) {
+	// проверяем источники секретов
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directory := DirectorySecretsProvider{Dir: dir}
+	value, err := directory.GetSecret("token")
+	if err != nil || value != "from-file" {
+		t.Errorf("expected from-file got %q %v", value, err)
+	}
+	if _, err = directory.GetSecret("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected not found error got %v", err)
+	}
+	if _, err = directory.GetSecret("../token"); err == nil {
+		t.Errorf("expected error on path in secret name")
+	}
+
+	os.Setenv("HANDWITCH_TEST_TOKEN", "from-env")
+	defer os.Unsetenv("HANDWITCH_TEST_TOKEN")
+	value, err = EnvSecretsProvider{Prefix: "HANDWITCH_TEST_"}.GetSecret("TOKEN")
+	if err != nil || value != "from-env" {
+		t.Errorf("expected from-env got %q %v", value, err)
+	}
+
+	key, err := ParseSecretsKey(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatalf("unexpected key error %s", err.Error())
+	}
+	encrypted, err := EncryptSecrets(map[string]string{"token": "encrypted"}, key)
+	if err != nil {
+		t.Fatalf("unexpected encryption error %s", err.Error())
+	}
+	path := filepath.Join(dir, "secrets.enc")
+	err = ioutil.WriteFile(path, encrypted, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewEncryptedFileSecretsProvider(path, key)
+	if err != nil {
+		t.Fatalf("unexpected decryption error %s", err.Error())
+	}
+	value, err = provider.GetSecret("token")
+	if err != nil || value != "encrypted" {
+		t.Errorf("expected encrypted got %q %v", value, err)
+	}
+	wrongKey, _ := ParseSecretsKey(strings.Repeat("cd", 32))
+	if _, err = NewEncryptedFileSecretsProvider(path, wrongKey); err == nil {
+		t.Errorf("expected error on wrong key")
+	}
+	if _, err = ParseSecretsKey("short"); err != ErrInvalidSecretsKey {
+		t.Errorf("expected invalid key error got %v", err)
+	}
+}
+
+func TestDescriptionSecrets(t *testing.T) {
+	// проверяем подстановку секретов при загрузке описаний
+	var gotHeader string
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		gotHeader = req.Header.Get("X-Token")
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{}`))
+	}))
+	defer serv.Close()
+
+	secrets := mapSecrets{"HOST": serv.URL, "token": "t0ken", "user": "admin"}
+	description := `hand:
+  url_template: ${HOST}/items?key={{ secret "token" }}
+  headers:
+    X-Token: '{{ secret "token" }}'
+  parameters:
+    owner:
+      name: owner
+      destination: query
+      type: string
+      default_value: ${user}
+  body: "{{ .meta.url }}"
+  url_name: hand
+  help: ""`
+	source, err := GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), secrets)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	processor := NewURLProcessor(source, serv.Client())
+	hand, err := processor.GetHand("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var builder strings.Builder
+	err = hand.Process(context.Background(), &builder, map[string]interface{}{}, log.NewEntry(log.New()))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if gotHeader != "t0ken" {
+		t.Errorf("expected secret header got %s", gotHeader)
+	}
+	// значения ${...} тоже секреты и скрываются
+	expectedURL := "***/items?key=***&owner=***"
+	if builder.String() != expectedURL {
+		t.Errorf("expected masked url %s got %s", expectedURL, builder.String())
+	}
+
+	// секреты не попадают в помощь
+	var help strings.Builder
+	err = hand.WriteHelp(&help)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	if strings.Contains(help.String(), "t0ken") || !strings.Contains(help.String(), "key={{ *** }}") {
+		t.Errorf("secret is not masked in help %s", help.String())
+	}
+
+	// отсутствующие секреты обнаруживаются при загрузке
+	_, err = GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), mapSecrets{"HOST": serv.URL})
+	if err == nil {
+		t.Fatalf("expected error on missing secrets")
+	}
+	for _, expected := range []string{"url_template: secret token", "header X-Token: secret token", "default value of owner: secret user"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error %s", expected, err.Error())
+		}
+	}
+}
+
+func TestEnvSecretsMaskedInHelp(t *testing.T) {
+	// проверяем, что значения ${...} скрываются в помощи по ручке
+	description := `hand:
+  url_template: http://localhost/items?token=${TOKEN}
+  headers:
+    X-Api-Key: ${TOKEN}
+  body: ok
+  url_name: hand
+  help: ""`
+	source, err := GetDescriptionSourceFromYAMLWithSecrets(strings.NewReader(description), mapSecrets{"TOKEN": "t0ken"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	processor := NewURLProcessor(source, http.DefaultClient)
+	hand, err := processor.GetHand("hand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var help strings.Builder
+	err = hand.WriteHelp(&help)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	for _, expected := range []string{"URL template: http://localhost/items?token=***", "X-Api-Key: ***"} {
+		if !strings.Contains(help.String(), expected) {
+			t.Errorf("expected %q in help %s", expected, help.String())
+		}
+	}
+	if strings.Contains(help.String(), "t0ken") {
+		t.Errorf("secret is not masked in help %s", help.String())
+	}
+}
+
+func TestShortSecretsNotMasked(t *testing.T) {
+	// проверяем, что короткие значения секретов не портят текст при скрытии
+	masked := maskSecretValues([]string{"1", "on", "t0ken"}, "page=1&verbose=on&token=t0ken")
+	if masked != "page=1&verbose=on&token=***" {
+		t.Errorf("unexpected masked text %q", masked)
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// stepsKey key of steps results in templates data
+const stepsKey = "steps"
+
+//StepRecord one request of multi-step hand, all templates of the step
+// get hand params and results of previous steps as .steps.{name}
+type StepRecord struct {
+	Name           string            `json:"name" yaml:"name"`
+	URLTemplate    string            `json:"URL_template" yaml:"url_template"`
+	Method         string            `json:"method" yaml:"method"`
+	Params         map[string]string `json:"params" yaml:"params"`
+	Headers        map[string]string `json:"headers" yaml:"headers"`
+	RequestBody    *RequestBody      `json:"request_body" yaml:"request_body"`
+	ResponseFormat ResponseFormat    `json:"response_format" yaml:"response_format"`
+}
+
+// stepResult executed step data available in templates
+type stepResult struct {
+	responce   *handResponce
+	displayURL string
+}
+
+func (result *stepResult) templateData() map[string]interface{} {
+	return map[string]interface{}{
+		"responce": result.responce.Data,
+		"url":      result.displayURL,
+		"status":   result.responce.Status,
+		"headers":  flattenHeaders(result.responce.Header),
+	}
+}
+
+// stepRecord build URLRecord to execute step with hand settings
+func (processor *HandProcessorImp) stepRecord(step *StepRecord) *URLRecord {
+	return &URLRecord{
+		URLTemplate:    step.URLTemplate,
+		Method:         step.Method,
+		RequestBody:    step.RequestBody,
+		Headers:        step.Headers,
+		Auth:           processor.Auth,
+		ResponseFormat: step.ResponseFormat,
+		URLName:        fmt.Sprintf("%s.%s", processor.URLName, step.Name),
+		Timeout:        processor.Timeout,
+		Retries:        processor.Retries,
+		RetryBackoff:   processor.RetryBackoff,
+		RetryOn:        processor.RetryOn,
+		RetryUnsafe:    processor.RetryUnsafe,
+		secretValues:   processor.secretValues,
+	}
+}
+
+// withSteps copy params with steps results added
+func withSteps(params map[string]interface{}, results map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(params)+1)
+	for name, value := range params {
+		data[name] = value
+	}
+	data[stepsKey] = results
+	return data
+}
+
+// runSteps execute hand steps one by one, execution is stopped
+// on the first failed step, it's result is returned as last
+func (processor *HandProcessorImp) runSteps(ctx context.Context, params map[string]interface{}, logger *log.Entry) (map[string]interface{}, *stepResult, error) {
+	results := make(map[string]interface{})
+	var last *stepResult
+	for i := range processor.Steps {
+		step := &processor.Steps[i]
+		stepLogger := logger.WithField("step", step.Name)
+		stepProcessor := newHandProcessor(processor.stepRecord(step), processor.client, processor.tokens, nil)
+		stepProcessor.query = step.Params
+
+		responce, requestURL, err := stepProcessor.sendWithRetries(ctx, withSteps(params, results), nil, stepLogger)
+		if err != nil {
+			return results, nil, fmt.Errorf("Failed to execute step %s: %w", step.Name, err)
+		}
+		err = stepProcessor.decode(responce, stepLogger)
+		if err != nil {
+			return results, nil, fmt.Errorf("Failed to decode step %s: %w", step.Name, err)
+		}
+		last = &stepResult{
+			responce:   responce,
+			displayURL: stepProcessor.maskSecrets(requestURL),
+		}
+		results[step.Name] = last.templateData()
+		if isErrorStatus(responce.Status) {
+			stepLogger.Warnf("Step failed with status %d, skipping next steps", responce.Status)
+			break
+		}
+	}
+	return results, last, nil
+}
+
+// renderQuery add templated query params to request
+func renderQuery(req *http.Request, query map[string]string, data map[string]interface{}) error {
+	if len(query) == 0 {
+		return nil
+	}
+	qry := req.URL.Query()
+	for name, valueTemplate := range query {
+		value, err := renderTemplate(name, valueTemplate, data)
+		if err != nil {
+			return fmt.Errorf("Failed to build query param %s: %w", name, err)
+		}
+		qry.Add(name, value)
+	}
+	req.URL.RawQuery = qry.Encode()
+	return nil
+}
+
+func validateSteps(urlRecord *URLRecord) []error {
+	errs := make([]error, 0)
+	names := make(map[string]struct{})
+	for i, step := range urlRecord.Steps {
+		stepErrs := make([]error, 0)
+		if step.Name == "" {
+			stepErrs = append(stepErrs, errors.New("step name is required"))
+		}
+		if step.Name == stepsKey {
+			stepErrs = append(stepErrs, fmt.Errorf("step name %s is reserved", stepsKey))
+		}
+		if _, ok := names[step.Name]; ok {
+			stepErrs = append(stepErrs, fmt.Errorf("duplicated step name %s", step.Name))
+		}
+		names[step.Name] = struct{}{}
+		if step.URLTemplate == "" {
+			stepErrs = append(stepErrs, errors.New("step url_template is required"))
+		}
+		err := validateMethod(step.Method)
+		if err != nil {
+			stepErrs = append(stepErrs, err)
+		}
+		err = validateResponseFormat(step.ResponseFormat)
+		if err != nil {
+			stepErrs = append(stepErrs, err)
+		}
+		stepRecord := URLRecord{
+			Method:      step.Method,
+			RequestBody: step.RequestBody,
+		}
+		stepErrs = append(stepErrs, validateRequestBody(&stepRecord)...)
+		if len(stepErrs) != 0 {
+			errs = append(errs, newValidationError(fmt.Sprintf("step %d %s", i, step.Name), stepErrs))
+		}
+	}
+	if _, ok := urlRecord.Parameters[stepsKey]; ok && len(urlRecord.Steps) != 0 {
+		errs = append(errs, fmt.Errorf("param name %s is reserved for steps results", stepsKey))
+	}
+	return errs
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSteps(t *testing.T) {
+	// проверяем цепочку запросов, использующую результаты предыдущих шагов
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		var rsp interface{}
+		switch req.URL.Path {
+		case "/users":
+			if req.URL.Query().Get("email") != "user@example.com" {
+				rw.WriteHeader(http.StatusNotFound)
+				rsp = map[string]interface{}{"error": "no such user"}
+				break
+			}
+			rsp = []interface{}{map[string]interface{}{"id": 7}}
+		case "/users/7/orders":
+			rsp = map[string]interface{}{"orders": []interface{}{"a", "b"}}
+		case "/summary":
+			rsp = map[string]interface{}{"user": req.URL.Query().Get("user")}
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(rsp)
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	steps := []StepRecord{
+		{
+			Name:        "user",
+			URLTemplate: serv.URL + "/users",
+			Params: map[string]string{
+				"email": "{{ .email }}",
+			},
+		},
+		{
+			Name:        "orders",
+			URLTemplate: serv.URL + `/users/{{ (index .steps.user.responce 0).id }}/orders`,
+		},
+	}
+	params := ParamsDescription{
+		"email": ParamInfo{
+			Name:        "email",
+			Type:        StringType,
+			Destination: QueryPlaced,
+		},
+	}
+	processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+		"last_step": {
+			Steps:      steps,
+			Parameters: params,
+			Body:       `User {{ (index .steps.user.responce 0).id }} has {{ len .responce.orders }} orders`,
+			ErrorBody:  `Failed: {{ .responce.error }}`,
+			URLName:    "last_step",
+		},
+		"with_request": {
+			URLTemplate: serv.URL + "/summary?user={{ (index .steps.user.responce 0).id }}",
+			Steps:       steps,
+			Parameters:  params,
+			Body:        `Summary for {{ .responce.user }} with {{ len .steps.orders.responce.orders }} orders`,
+			URLName:     "with_request",
+		},
+	}), serv.Client())
+
+	testCases := []struct {
+		Name   string
+		Hand   string
+		Email  string
+		Output string
+	}{
+		{
+			Name:   "result of last step",
+			Hand:   "last_step",
+			Email:  "user@example.com",
+			Output: "User 7 has 2 orders",
+		},
+		{
+			Name:   "failed step",
+			Hand:   "last_step",
+			Email:  "unknown@example.com",
+			Output: "Failed: no such user",
+		},
+		{
+			Name:   "request after steps",
+			Hand:   "with_request",
+			Email:  "user@example.com",
+			Output: "Summary for 7 with 2 orders",
+		},
+	}
+	for _, testCase := range testCases {
+		hand, err := processor.GetHand(testCase.Hand)
+		if err != nil {
+			t.Fatalf("%s: failed to get hand %s", testCase.Name, err.Error())
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{"email": testCase.Email}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output %s got %s", testCase.Name, testCase.Output, buf.String())
+		}
+	}
+}
+
+func TestStepsRetries(t *testing.T) {
+	// проверяем, что шаги повторяются с настройками ручки, в том числе retry_unsafe
+	var attempts int32
+	serv := httptest.NewServer(jsonHandler(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		err := json.NewEncoder(rw).Encode(map[string]interface{}{"id": 7})
+		if err != nil {
+			panic(err.Error())
+		}
+	}))
+	defer serv.Close()
+
+	testCases := []struct {
+		Name        string
+		RetryUnsafe bool
+		Attempts    int32
+		Output      string
+	}{
+		{
+			Name:        "unsafe step retried",
+			RetryUnsafe: true,
+			Attempts:    2,
+			Output:      "Created 7",
+		},
+		{
+			Name:        "unsafe step not retried",
+			RetryUnsafe: false,
+			Attempts:    1,
+			Output:      "Failed 503",
+		},
+	}
+	for _, testCase := range testCases {
+		atomic.StoreInt32(&attempts, 0)
+		processor := NewURLProcessor(NewDescriptionSourceFromDict(URLContrainer{
+			"create": {
+				Steps: []StepRecord{
+					{
+						Name:        "create",
+						URLTemplate: serv.URL + "/items",
+						Method:      http.MethodPost,
+					},
+				},
+				Body:         `Created {{ .steps.create.responce.id }}`,
+				ErrorBody:    `Failed {{ .meta.status }}`,
+				URLName:      "create",
+				Retries:      2,
+				RetryBackoff: Duration(time.Millisecond),
+				RetryUnsafe:  testCase.RetryUnsafe,
+			},
+		}), serv.Client())
+		hand, err := processor.GetHand("create")
+		if err != nil {
+			t.Fatalf("%s: failed to get hand %s", testCase.Name, err.Error())
+		}
+		buf := new(bytes.Buffer)
+		err = hand.Process(context.Background(), buf, map[string]interface{}{}, log.NewEntry(&log.Logger{}))
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.Name, err.Error())
+			continue
+		}
+		if buf.String() != testCase.Output {
+			t.Errorf("%s: expected output %s got %s", testCase.Name, testCase.Output, buf.String())
+		}
+		if atomic.LoadInt32(&attempts) != testCase.Attempts {
+			t.Errorf("%s: expected %d attempts got %d", testCase.Name, testCase.Attempts, atomic.LoadInt32(&attempts))
+		}
+	}
+}